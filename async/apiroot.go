@@ -19,21 +19,32 @@ import (
 	v3 "github.com/golangee/openapi/v3"
 )
 
-func emitApiRoot(f *gen.GoGenFile, doc *v3.Document) (string, error) {
+func emitApiRoot(opts Options, f *gen.GoGenFile, doc *v3.Document) (string, error) {
+	f.ImportName("bytes", "")
 	f.ImportName("context", "")
+	f.ImportName("fmt", "")
+	f.ImportName("encoding", "")
+	f.ImportName("encoding/base64", "")
 	f.ImportName("encoding/json", "")
+	f.ImportName("errors", "")
 	f.ImportName("io", "")
+	f.ImportName("mime/multipart", "")
 	f.ImportName("net/http", "")
 	f.ImportName("net/url", "")
 	f.ImportName("io/ioutil", "")
 	f.ImportName("reflect", "")
+	f.ImportName("sort", "")
+	f.ImportName("strconv", "")
+	f.ImportName("strings", "")
 	//f.ImportName("log","")
 
-	f.Printf(errTypeStub)
-	f.Printf("\n")
+	if err := emitErrorModel(opts, f); err != nil {
+		return "", err
+	}
 	f.Printf(gen.Comment(doc.Info.Description))
 	rootName := gen.Public(doc.Info.Title + "Service")
 	f.Printf(parentClientStub, rootName)
+	f.Printf("%s", paramStub)
 	return rootName, nil
 }
 
@@ -52,8 +63,15 @@ func New%[1]s(baseURL *url.URL, userAgent string, httpClient *http.Client) *%[1]
 	return &%[1]s{baseURL: baseURL, httpClient: httpClient, userAgent: userAgent}
 }
 
-func (s *%[1]s) newRequest(ctx context.Context, method, path, contentType, accept string, body io.Reader) (*http.Request, error) {
-	rel := &url.URL{Path: path}
+// newRequest resolves the already escaped path against the base url and attaches the optional query.
+func (s *%[1]s) newRequest(ctx context.Context, method, path string, query url.Values, contentType, accept string, body io.Reader) (*http.Request, error) {
+	rel, err := url.Parse(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(query) > 0 {
+		rel.RawQuery = query.Encode()
+	}
 	u := s.baseURL.ResolveReference(rel)
 	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
 	if err != nil {
@@ -63,32 +81,59 @@ func (s *%[1]s) newRequest(ctx context.Context, method, path, contentType, accep
 		req.Header.Set("Content-Type", contentType)
 	}
 
-	req.Header.Set("Accept", accept)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
 	if s.userAgent != "" {
 		req.Header.Set("User-Agent", s.userAgent)
 	}
 	return req, nil
 }
 
-func (s *%[1]s) doJson(req *http.Request, v interface{}) (*http.Response, error) {
+// do executes the request and reads the entire response. Any status code other than 2xx is returned as error,
+// which is either decoded by the optional parseErr or as ResponseError. Error bodies are read only up to
+// maxErrorBodySize.
+func (s *%[1]s) do(req *http.Request, parseErr func(resp *http.Response, buf []byte) error) (*http.Response, []byte, error) {
 	resp, err := s.httpClient.Do(req)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode == 200 {
-		err = json.NewDecoder(resp.Body).Decode(v)
-		return resp, err
-	}else{
-		err := ParseError(resp.Body)
-		return resp, err
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		buf, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+		if err != nil {
+			return resp, nil, &ResponseError{StatusCode: resp.StatusCode, Header: resp.Header, RawBody: buf, Cause: err}
+		}
+
+		if parseErr != nil {
+			if err := parseErr(resp, buf); err != nil {
+				return resp, nil, err
+			}
+		}
+		return resp, nil, &ResponseError{StatusCode: resp.StatusCode, Header: resp.Header, RawBody: buf, Cause: parseErrorModel(resp, buf)}
 	}
-	
+
+	buf, err := ioutil.ReadAll(resp.Body)
+	return resp, buf, err
 }
 
+// doJson executes the request and decodes a successful response into v. An empty body leaves v untouched and
+// v may be nil to discard the response.
+func (s *%[1]s) doJson(req *http.Request, v interface{}, parseErr func(resp *http.Response, buf []byte) error) (*http.Response, error) {
+	resp, buf, err := s.do(req, parseErr)
+	if err != nil {
+		return resp, err
+	}
+
+	if v == nil || len(bytes.TrimSpace(buf)) == 0 {
+		return resp, nil
+	}
+
+	return resp, json.Unmarshal(buf, v)
+}
 
 `
 
 // copied from http/error.go
-const errTypeStub = "// Error describes a (nested) server error\ntype Error struct {\n\tId               string      `json:\"id\"`                         // Id is unique for a specific error, e.g. mydomain.not.assigned\n\tMessage          string      `json:\"message\"`                    // Message is a string for the developer\n\tLocalizedMessage string      `json:\"localizedMessage,omitempty\"` // LocalizedMessage is something to display the user\n\tCausedBy         *Error      `json:\"causedBy,omitempty\"`         // CausedBy returns an optional root error\n\tType             string      `json:\"type,omitempty\"`             // Type is a developer notice for the internal inspection\n\tDetails          interface{} `json:\"details,omitempty\"`          // Details contains arbitrary payload\n}\n\n// WrapError takes the cause and converts it into an Error for later serialization.\nfunc WrapError(id string, causedBy error) *Error {\n\tmsg := id\n\tif causedBy != nil {\n\t\tmsg = causedBy.Error()\n\t}\n\treturn &Error{Id: id, Message: msg, CausedBy: AsError(causedBy)}\n}\n\n// ParseError tries to parse the response as json. In any case it returns an error.\nfunc ParseError(reader io.Reader) *Error {\n\tbuf, err := ioutil.ReadAll(reader)\n\tif err != nil {\n\t\treturn AsError(err)\n\t}\n\n\tres := &Error{}\n\terr = json.Unmarshal(buf, res)\n\tif err != nil {\n\t\treturn AsError(err)\n\t}\n\n\treturn res\n}\n\n// ID returns the unique error class id\nfunc (c *Error) ID() string {\n\treturn c.Id\n}\n\n// Error returns the message\nfunc (c *Error) Error() string {\n\treturn c.Message\n}\n\n// LocalizedError is like Error but translated or empty\nfunc (c *Error) LocalizedError() string {\n\treturn c.LocalizedMessage\n}\n\n// Class returns the technical type\nfunc (c *Error) Class() string {\n\treturn c.Type\n}\n\n// Payload returns the details\nfunc (c *Error) Payload() interface{} {\n\treturn c.Details\n}\n\n// Unwrap returns the cause or nil\nfunc (c *Error) Unwrap() error {\n\tif c.CausedBy == nil { // otherwise error iface will not be nil, because of the type info in interface\n\t\treturn nil\n\t}\n\treturn c.CausedBy\n}\n\nfunc (c *Error) String() string {\n\tbuf, err2 := json.Marshal(AsError(c))\n\tif err2 != nil {\n\t\treturn fmt.Errorf(\"suppressed error by: %w\", err2).Error()\n\t}\n\treturn string(buf)\n}\n\n// FindError returns the first occurrence of the error identified by id or nil.\nfunc FindError(err error, id string) *Error {\n\tif err == nil {\n\t\treturn nil\n\t}\n\n\te := AsError(err)\n\tif e.Id == id {\n\t\treturn e\n\t}\n\n\tif e.CausedBy != nil {\n\t\treturn FindError(e.CausedBy, id)\n\t}\n\n\treturn nil\n}\n\n// AsError either casts the given error (if possible) or creates a new Error from the given error. Returns only\n// nil if err is nil.\nfunc AsError(err error) *Error {\n\tif err == nil {\n\t\treturn nil\n\t}\n\n\tif e, ok := err.(*Error); ok {\n\t\treturn e\n\t}\n\n\te := &Error{}\n\te.Type = reflect.TypeOf(err).String()\n\te.Message = err.Error()\n\n\tif code, ok := err.(interface{ ID() string }); ok {\n\t\te.Id = code.ID()\n\t} else {\n\t\te.Id = e.Type\n\t}\n\n\tif details, ok := err.(interface{ Payload() interface{} }); ok {\n\t\te.Details = details\n\t}\n\n\tif localized, ok := err.(interface{ LocalizedError() string }); ok {\n\t\te.LocalizedMessage = localized.LocalizedError()\n\t}\n\n\tif class, ok := err.(interface{ Class() string }); ok {\n\t\te.Type = class.Class()\n\t}\n\n\tif wrapper, ok := err.(interface{ Unwrap() error }); ok {\n\t\tcause := wrapper.Unwrap()\n\t\tif cause != nil {\n\t\t\ttmp := AsError(cause)\n\t\t\te.CausedBy = tmp\n\t\t}\n\t}\n\n\treturn e\n}"
\ No newline at end of file
+const errTypeStub = "// Error describes a (nested) server error\ntype Error struct {\n\tId               string      `json:\"id\"`                         // Id is unique for a specific error, e.g. mydomain.not.assigned\n\tMessage          string      `json:\"message\"`                    // Message is a string for the developer\n\tLocalizedMessage string      `json:\"localizedMessage,omitempty\"` // LocalizedMessage is something to display the user\n\tCausedBy         *Error      `json:\"causedBy,omitempty\"`         // CausedBy returns an optional root error\n\tType             string      `json:\"type,omitempty\"`             // Type is a developer notice for the internal inspection\n\tDetails          interface{} `json:\"details,omitempty\"`          // Details contains arbitrary payload\n}\n\n// WrapError takes the cause and converts it into an Error for later serialization.\nfunc WrapError(id string, causedBy error) *Error {\n\tmsg := id\n\tif causedBy != nil {\n\t\tmsg = causedBy.Error()\n\t}\n\treturn &Error{Id: id, Message: msg, CausedBy: AsError(causedBy)}\n}\n\n// ParseError tries to parse the response as json. In any case it returns an error.\nfunc ParseError(reader io.Reader) *Error {\n\tbuf, err := ioutil.ReadAll(reader)\n\tif err != nil {\n\t\treturn AsError(err)\n\t}\n\n\tres := &Error{}\n\terr = json.Unmarshal(buf, res)\n\tif err != nil {\n\t\treturn AsError(err)\n\t}\n\n\treturn res\n}\n\n// ID returns the unique error class id\nfunc (c *Error) ID() string {\n\treturn c.Id\n}\n\n// Error returns the message\nfunc (c *Error) Error() string {\n\treturn c.Message\n}\n\n// LocalizedError is like Error but translated or empty\nfunc (c *Error) LocalizedError() string {\n\treturn c.LocalizedMessage\n}\n\n// Class returns the technical type\nfunc (c *Error) Class() string {\n\treturn c.Type\n}\n\n// Payload returns the details\nfunc (c *Error) Payload() interface{} {\n\treturn c.Details\n}\n\n// Unwrap returns the cause or nil\nfunc (c *Error) Unwrap() error {\n\tif c.CausedBy == nil { // otherwise error iface will not be nil, because of the type info in interface\n\t\treturn nil\n\t}\n\treturn c.CausedBy\n}\n\nfunc (c *Error) String() string {\n\tbuf, err2 := json.Marshal(AsError(c))\n\tif err2 != nil {\n\t\treturn fmt.Errorf(\"suppressed error by: %w\", err2).Error()\n\t}\n\treturn string(buf)\n}\n\n// FindError returns the first occurrence of the error identified by id or nil.\nfunc FindError(err error, id string) *Error {\n\tif err == nil {\n\t\treturn nil\n\t}\n\n\te := AsError(err)\n\tif e.Id == id {\n\t\treturn e\n\t}\n\n\tif e.CausedBy != nil {\n\t\treturn FindError(e.CausedBy, id)\n\t}\n\n\treturn nil\n}\n\n// AsError either casts the given error (if possible) or creates a new Error from the given error. Returns only\n// nil if err is nil.\nfunc AsError(err error) *Error {\n\tif err == nil {\n\t\treturn nil\n\t}\n\n\tif e, ok := err.(*Error); ok {\n\t\treturn e\n\t}\n\n\te := &Error{}\n\te.Type = reflect.TypeOf(err).String()\n\te.Message = err.Error()\n\n\tif code, ok := err.(interface{ ID() string }); ok {\n\t\te.Id = code.ID()\n\t} else {\n\t\te.Id = e.Type\n\t}\n\n\tif details, ok := err.(interface{ Payload() interface{} }); ok {\n\t\te.Details = details\n\t}\n\n\tif localized, ok := err.(interface{ LocalizedError() string }); ok {\n\t\te.LocalizedMessage = localized.LocalizedError()\n\t}\n\n\tif class, ok := err.(interface{ Class() string }); ok {\n\t\te.Type = class.Class()\n\t}\n\n\tif wrapper, ok := err.(interface{ Unwrap() error }); ok {\n\t\tcause := wrapper.Unwrap()\n\t\tif cause != nil {\n\t\t\ttmp := AsError(cause)\n\t\t\te.CausedBy = tmp\n\t\t}\n\t}\n\n\treturn e\n}"
@@ -15,9 +15,12 @@
 package async
 
 import (
+	"fmt"
 	"github.com/golangee/openapi-client/internal/gen"
 	v3 "github.com/golangee/openapi/v3"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 )
 
@@ -27,19 +30,35 @@ type endpoint struct {
 	op     *v3.Operation
 }
 
+// contentType returns the media type of the request body or the empty string, if the operation does not declare
+// a body at all.
 func (e endpoint) contentType() string {
-	return "" //TODO
+	if e.op.RequestBody == nil {
+		return ""
+	}
+	return pickMediaType(e.op.RequestBody.Content)
 }
 
+// acceptType returns the media type of the success response or the empty string, if no content is expected.
 func (e endpoint) acceptType() string {
-	return "application/json" //TODO
+	_, response := pickSuccessResponse(e.op)
+	if response == nil {
+		return ""
+	}
+	return pickMediaType(response.Content)
 }
 
+// defaultGroup collects all untagged operations. The title of the document cannot be used, because the api root
+// is already named after it.
+const defaultGroup = "default"
+
 func emitCallGroups(opts Options, f *gen.GoGenFile, parentType string, doc *v3.Document) error {
 	groups := map[string][]endpoint{}
+	var all []endpoint
 	for path, call := range doc.Paths {
 		for method, op := range call.Map() {
-			tmpTags := []string{doc.Info.Title}
+			all = append(all, endpoint{path, method, op})
+			tmpTags := []string{defaultGroup}
 			if len(op.Tags) > 0 {
 				tmpTags = op.Tags
 			}
@@ -53,8 +72,21 @@ func emitCallGroups(opts Options, f *gen.GoGenFile, parentType string, doc *v3.D
 		}
 	}
 
+	// error types are declared once per operation, even if it belongs to multiple groups
+	sort.Slice(all, func(i, j int) bool {
+		return methodName(all[i]) < methodName(all[j])
+	})
+	for _, ep := range all {
+		if err := emitErrorTypes(opts, f, doc, ep); err != nil {
+			return diagnosticAt(operationPointer(ep), err)
+		}
+	}
+
 	for _, tag := range gen.SortedKeys(groups) {
 		endpoints := groups[tag]
+		sort.Slice(endpoints, func(i, j int) bool {
+			return methodName(endpoints[i]) < methodName(endpoints[j])
+		})
 		err := emitCallGroup(opts, f, doc, parentType, gen.Public(tag)+"Service", endpoints)
 		if err != nil {
 			return err
@@ -77,72 +109,200 @@ func emitCallGroup(opts Options, f *gen.GoGenFile, doc *v3.Document, parentType
 	for _, ep := range endpoints {
 		err := emitSyncCall(opts, f, doc, name, ep)
 		if err != nil {
-			return err
+			return diagnosticAt(operationPointer(ep), err)
 		}
 
 		err = emitAsyncCall(opts, f, doc, name, ep)
 		if err != nil {
-			return err
+			return diagnosticAt(operationPointer(ep), err)
 		}
 	}
 	return nil
 }
 
 func emitSyncCall(opts Options, f *gen.GoGenFile, doc *v3.Document, receiverTypeName string, ep endpoint) error {
-	resType := pickResponseAndResolveTypeName(opts, f, doc, ep)
+	resType, err := pickResponseAndResolveTypeName(opts, f, doc, ep)
+	if err != nil {
+		return err
+	}
+
+	paramTypes, err := paramTypeNames(opts, f, doc, ep)
+	if err != nil {
+		return err
+	}
+
+	bodyType, err := requestBodyTypeName(opts, f, doc, ep)
+	if err != nil {
+		return err
+	}
+
+	retErr := "_err"
+	if resType != "" {
+		retErr = "_res,_err"
+	}
 	f.Printf(gen.Comment(ep.op.Description))
+	names := paramNames(f, ep)
 	f.Printf("func (_self %s) sync%s(_ctx %s", receiverTypeName, methodName(ep), f.ImportName("context", "Context"))
-	for _, inParam := range ep.op.Parameters {
-		tname := typeName(opts, f, doc, inParam.Schema)
-		f.Printf(",%s %s", inParam.Name, tname)
+	for i := range ep.op.Parameters {
+		f.Printf(",%s %s", names[i], paramTypes[i])
+	}
+	if bodyType != "" {
+		f.Printf(",body %s", bodyType)
+	}
+	if resType == "" {
+		f.Printf(",) error{\n")
+	} else {
+		f.Printf(",) (%s,error){\n", resType)
+		f.Printf("var _res %s\n", resType)
 	}
-	f.Printf(",) (%s,error){\n", resType)
 
-	f.Printf("var _res %s\n", resType)
 	pathParams := pathParamsToSprintf(ep.path)
-
-	query := "?"
-	escapeParams := ""
-	for _, inParam := range ep.op.Parameters {
-		if inParam.In == v3.QueryLocation {
-			query += "&" + inParam.Name + "=%s"
-			imp := f.ImportName("net/url", "QueryEscape")
-			printf := f.ImportName("fmt", "Sprintf")
-			escapeParams += imp + "(" + printf + "(\"%v\"," + inParam.Name + ")),"
+	var pathArgs []string
+	for _, name := range pathParams.params {
+		idx, inParam := findParam(ep, v3.PathLocation, name)
+		style, explode := paramStyle(inParam)
+		local := paramName(name)
+		if idx >= 0 {
+			local = names[idx]
 		}
+		pathArgs = append(pathArgs, fmt.Sprintf("encodePathParam(%q,%q,%v,%s)", name, style, explode, local))
 	}
 
-	f.Printf("path := %s(\"%s\",%s)\n", f.ImportName("fmt", "Sprintf"), pathParams.sprintfPath, strings.Join(pathParams.params, ","))
-	f.Printf("path += %s(\"%s\",%s)\n", f.ImportName("fmt", "Sprintf"), query, escapeParams)
-	// newRequest(ctx context.Context, method, path, contentType, accept string, body io.Reader) (*http.Request, error)
-	f.Printf("_req,_err := _self.parent.newRequest(_ctx, \"%s\", path, \"%s\",\"%s\",nil)\n", ep.method, ep.contentType(), ep.acceptType())
+	f.Printf("path := %s(\"%s\",%s)\n", f.ImportName("fmt", "Sprintf"), pathParams.sprintfPath, strings.Join(pathArgs, ","))
+	query := emitQueryParams(f, ep, names)
+	bodyReader, contentType, err := emitRequestBody(f, doc, ep, bodyType, retErr)
+	if err != nil {
+		return err
+	}
+
+	// newRequest(ctx context.Context, method, path string, query url.Values, contentType, accept string, body io.Reader) (*http.Request, error)
+	f.Printf("_req,_err := _self.parent.newRequest(_ctx, \"%s\", path, %s, %s,\"%s\",%s)\n", ep.method, query, contentType, ep.acceptType(), bodyReader)
 	f.Printf("if _err != nil {\n")
-	f.Printf("return _res,_err\n")
+	f.Printf("return %s\n", retErr)
 	f.Printf("}\n")
+	emitHeaderAndCookieParams(f, ep, names)
 
-	// doJson(req *http.Request, v interface{}) (*http.Response, error)
-	f.Printf("_,_err =_self.parent.doJson(_req,&_res)\n")
-	f.Printf("return _res,_err\n")
+	switch {
+	case resType == "":
+		// doJson(req *http.Request, v interface{}) (*http.Response, error)
+		f.Printf("_,_err =_self.parent.doJson(_req,nil,%s)\n", errorParserName(ep))
+	case isJsonMediaType(ep.acceptType()):
+		f.Printf("_,_err =_self.parent.doJson(_req,&_res,%s)\n", errorParserName(ep))
+	default:
+		// do(req *http.Request, parseErr func(resp *http.Response, buf []byte) error) (*http.Response, []byte, error)
+		f.Printf("_,_buf,_err :=_self.parent.do(_req,%s)\n", errorParserName(ep))
+		f.Printf("_res = %s(_buf)\n", resType)
+	}
+	f.Printf("return %s\n", retErr)
 	f.Printf("}\n")
 	return nil
 }
 
+// emitRequestBody encodes the body according to the media type of the request and returns the reader and the
+// content type to pass to newRequest. Json media types are marshaled, objects are encoded as form fields, an
+// io.Reader is streamed and strings or byte slices are sent as is. Anything else cannot be encoded and results in
+// a diagnostic.
+func emitRequestBody(f *gen.GoGenFile, doc *v3.Document, ep endpoint, bodyType, retErr string) (string, string, error) {
+	mediaType := ep.contentType()
+	contentType := strconv.Quote(mediaType)
+	switch {
+	case bodyType == "":
+		return "nil", contentType, nil
+	case bodyType == f.ImportName("io", "Reader"):
+		return "body", contentType, nil // binary content is streamed as is
+	case isJsonMediaType(mediaType):
+		f.Printf("_body,_err := %s(body)\n", f.ImportName("encoding/json", "Marshal"))
+		f.Printf("if _err != nil {\n")
+		f.Printf("return %s\n", retErr)
+		f.Printf("}\n")
+		return f.ImportName("bytes", "NewReader") + "(_body)", contentType, nil
+	case isFormMediaType(mediaType, gen.FormMediaType) && isFormBody(doc, ep):
+		f.Printf("_form,_err := encodeForm(body)\n")
+		f.Printf("if _err != nil {\n")
+		f.Printf("return %s\n", retErr)
+		f.Printf("}\n")
+		return "_form", contentType, nil
+	case isFormMediaType(mediaType, gen.MultipartMediaType) && isFormBody(doc, ep):
+		// the content type declares the generated boundary
+		f.Printf("_contentType,_form,_err := encodeMultipart(body)\n")
+		f.Printf("if _err != nil {\n")
+		f.Printf("return %s\n", retErr)
+		f.Printf("}\n")
+		return "_form", "_contentType", nil
+	case strings.HasPrefix(mediaType, "multipart/"):
+		// a verbatim body would lack the boundary parameter of the content type
+	case bodyType == "[]byte":
+		return f.ImportName("bytes", "NewReader") + "(body)", contentType, nil
+	case bodyType == "string":
+		return f.ImportName("strings", "NewReader") + "(body)", contentType, nil
+	}
+
+	return "", "", newDiagnostic("/requestBody/content/"+gen.EscapeToken(mediaType), "unsupported media type '%s' for a request body of type %s", mediaType, bodyType)
+}
+
+// isFormMediaType checks if the media type, without parameters, equals the given form media type.
+func isFormMediaType(mediaType, formType string) bool {
+	if idx := strings.Index(mediaType, ";"); idx >= 0 {
+		mediaType = mediaType[:idx]
+	}
+	return strings.TrimSpace(strings.ToLower(mediaType)) == formType
+}
+
+// isFormBody checks if the request body is an object, whose properties can be encoded as form fields.
+func isFormBody(doc *v3.Document, ep endpoint) bool {
+	schema := ep.op.RequestBody.Content[ep.contentType()].Schema
+	if schema.Ref != nil {
+		_, resolved := doc.ResolveRef(*schema.Ref)
+		if resolved == nil {
+			return false
+		}
+		schema = *resolved
+	}
+	return isObject(schema) || len(schema.AllOf) > 0
+}
+
 func emitAsyncCall(opts Options, f *gen.GoGenFile, doc *v3.Document, receiverTypeName string, ep endpoint) error {
+	resType, err := pickResponseAndResolveTypeName(opts, f, doc, ep)
+	if err != nil {
+		return err
+	}
+
+	paramTypes, err := paramTypeNames(opts, f, doc, ep)
+	if err != nil {
+		return err
+	}
+
+	bodyType, err := requestBodyTypeName(opts, f, doc, ep)
+	if err != nil {
+		return err
+	}
+
 	f.Printf(gen.Comment(ep.op.Description))
+	names := paramNames(f, ep)
 	f.Printf("func (_self %s) %s(_ctx %s, ", receiverTypeName, methodName(ep), f.ImportName("context", "Context"))
-	for _, inParam := range ep.op.Parameters {
-		tname := typeName(opts, f, doc, inParam.Schema)
-		f.Printf("%s %s,", inParam.Name, tname)
+	for i := range ep.op.Parameters {
+		f.Printf("%s %s,", names[i], paramTypes[i])
+	}
+	if bodyType != "" {
+		f.Printf("body %s,", bodyType)
+	}
+	res := "res,"
+	if resType == "" {
+		res = ""
+		f.Printf("f func(err error)){\n")
+	} else {
+		f.Printf("f func(res %s,err error)){\n", resType)
 	}
-	f.Printf("f func(res %s,err error)){\n", pickResponseAndResolveTypeName(opts, f, doc, ep))
 	f.Printf("go func(){\n")
-	f.Printf("res,err := %s(_ctx", "_self.sync"+methodName(ep))
-	for _, inParam := range ep.op.Parameters {
-		f.Printf(",")
-		f.Printf(inParam.Name)
+	f.Printf("%serr := %s(_ctx", res, "_self.sync"+methodName(ep))
+	for _, name := range names {
+		f.Printf(",%s", name)
+	}
+	if bodyType != "" {
+		f.Printf(",body")
 	}
 	f.Printf(")\n")
-	f.Printf("f(res,err)\n")
+	f.Printf("f(%serr)\n", res)
 	f.Printf("}()\n")
 	f.Printf("}\n")
 	return nil
@@ -163,15 +323,111 @@ func methodName(ep endpoint) string {
 	return gen.SlashToCamelCase(method + "/" + path)
 }
 
-func pickResponseAndResolveTypeName(opts Options, f *gen.GoGenFile, doc *v3.Document, ep endpoint) string {
-	response, has200 := ep.op.Responses["200"]
-	if has200 {
-		json, hasJson := response.Content["application/json"]
-		if hasJson {
-			return typeName(opts, f, doc, json.Schema)
+// pickResponseAndResolveTypeName returns the type of the success response or the empty string, if the endpoint
+// has no result value, e.g. for 204 No Content. Json content is decoded into its declared type, other textual
+// content becomes a string and anything else is returned as a byte slice.
+func pickResponseAndResolveTypeName(opts Options, f *gen.GoGenFile, doc *v3.Document, ep endpoint) (string, error) {
+	code, response := pickSuccessResponse(ep.op)
+	if response == nil || code == "204" {
+		return "", nil
+	}
+
+	mediaType := pickMediaType(response.Content)
+	switch {
+	case mediaType == "":
+		return "", nil
+	case isJsonMediaType(mediaType):
+		tname, err := typeName(opts, f, doc, response.Content[mediaType].Schema)
+		if err != nil {
+			return "", diagnosticAt("/responses/"+gen.EscapeToken(code)+"/content/"+gen.EscapeToken(mediaType)+"/schema", err)
 		}
+		return tname, nil
+	case strings.HasPrefix(mediaType, "text/"):
+		return "string", nil
+	default:
+		return "[]byte", nil
+	}
+}
+
+// pickSuccessResponse returns the first declared 2xx response, otherwise the 2XX range or the default response.
+// Returns nil, if the operation declares none of them.
+func pickSuccessResponse(op *v3.Operation) (string, *v3.Response) {
+	for _, code := range gen.SortedKeys(op.Responses) {
+		if len(code) == 3 && code[0] == '2' && code != "2XX" {
+			response := op.Responses[code]
+			return code, &response
+		}
+	}
+
+	for _, code := range []string{"2XX", "default"} {
+		if response, has := op.Responses[code]; has {
+			return code, &response
+		}
+	}
+
+	return "", nil
+}
+
+// requestBodyTypeName resolves the type of the request body or returns the empty string, if the operation does
+// not declare a body.
+func requestBodyTypeName(opts Options, f *gen.GoGenFile, doc *v3.Document, ep endpoint) (string, error) {
+	if ep.op.RequestBody == nil {
+		return "", nil
+	}
+	media, has := ep.op.RequestBody.Content[ep.contentType()]
+	if !has {
+		return "", nil
+	}
+
+	if isBinaryBody(opts, media.Schema) {
+		return f.ImportName("io", "Reader"), nil
+	}
+
+	tname, err := typeName(opts, f, doc, media.Schema)
+	if err != nil {
+		return "", diagnosticAt("/requestBody/content/"+gen.EscapeToken(ep.contentType())+"/schema", err)
+	}
+	return tname, nil
+}
+
+// paramTypeNames resolves the types of all parameters in declaration order. Optional parameters are pointers.
+func paramTypeNames(opts Options, f *gen.GoGenFile, doc *v3.Document, ep endpoint) ([]string, error) {
+	res := make([]string, 0, len(ep.op.Parameters))
+	for i, inParam := range ep.op.Parameters {
+		tname, err := typeName(opts, f, doc, inParam.Schema)
+		if err != nil {
+			return nil, diagnosticAt("/parameters/"+strconv.Itoa(i)+"/schema", err)
+		}
+		res = append(res, optionalParamType(inParam, tname))
+	}
+	return res, nil
+}
+
+// pickMediaType prefers application/json, then any other json flavor (like application/merge-patch+json) and
+// finally the first declared media type.
+func pickMediaType(content map[string]v3.MediaType) string {
+	if _, has := content[ContentTypeJson]; has {
+		return ContentTypeJson
+	}
+	keys := gen.SortedKeys(content)
+	for _, key := range keys {
+		if isJsonMediaType(key) {
+			return key
+		}
+	}
+	if len(keys) > 0 {
+		return keys[0]
+	}
+	return ""
+}
+
+// isJsonMediaType checks for application/json and structured syntax suffixes like application/problem+json.
+func isJsonMediaType(mediaType string) bool {
+	if idx := strings.Index(mediaType, ";"); idx >= 0 {
+		mediaType = mediaType[:idx]
 	}
-	return "interface{}"
+	mediaType = strings.TrimSpace(strings.ToLower(mediaType))
+	return mediaType == ContentTypeJson || strings.HasSuffix(mediaType, "+json")
 }
 
 type namedPath struct {
@@ -182,11 +438,11 @@ type namedPath struct {
 
 func pathParamsToSprintf(path string) namedPath {
 	r := namedPath{path: path}
-	regex := regexp.MustCompile(`{\w*}`)
+	regex := regexp.MustCompile(`{[^}]*}`)
 	sprint := regex.ReplaceAllStringFunc(path, func(s string) string {
 		name := s[1 : len(s)-1]
 		r.params = append(r.params, name)
-		return "%v"
+		return "%s"
 	})
 	r.sprintfPath = sprint
 	return r
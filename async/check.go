@@ -0,0 +1,187 @@
+// Copyright 2020 Torben Schinke
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package async
+
+import (
+	"github.com/golangee/openapi-client/internal/gen"
+	v3 "github.com/golangee/openapi/v3"
+	"sort"
+)
+
+// unsupported collects the components and operations which cannot be generated.
+type unsupported struct {
+	components  map[string]*Diagnostic
+	operations  []endpoint
+	diagnostics Diagnostics
+}
+
+// checkSupported emits each component and each operation into a scratch file, to find everything which cannot be
+// generated. Components and operations which refer to an unsupported component are unsupported as well. Origins
+// contains the original pointers of hoisted components.
+func checkSupported(opts Options, doc *v3.Document, origins map[string]string) unsupported {
+	res := unsupported{components: map[string]*Diagnostic{}}
+	for _, name := range gen.SortedKeys(doc.Components.Schemas) {
+		if name == builtinErrorModelName(opts) {
+			continue
+		}
+
+		scratch := gen.NewGoGenFile(opts.TargetPackage, "openapi-client")
+		if err := emitType(opts, scratch, doc, name, doc.Components.Schemas[name]); err != nil {
+			res.components[name] = diagnosticAt(componentPointer(name), err)
+		}
+	}
+
+	// propagate until all transitive dependents are found
+	for changed := true; changed; {
+		changed = false
+		for _, name := range gen.SortedKeys(doc.Components.Schemas) {
+			if _, has := res.components[name]; has {
+				continue
+			}
+
+			if dependency, ok := unsupportedDependency(doc, res.components, doc.Components.Schemas[name]); ok {
+				res.components[name] = newDiagnostic(componentPointer(name), "depends on the unsupported schema %s", originPointer(origins, dependency))
+				changed = true
+			}
+		}
+	}
+
+	for _, name := range gen.SortedKeys(res.components) {
+		res.diagnostics = append(res.diagnostics, res.components[name])
+	}
+
+	for _, ep := range sortedEndpoints(doc) {
+		if d := checkOperation(opts, doc, origins, res.components, ep); d != nil {
+			res.operations = append(res.operations, ep)
+			res.diagnostics = append(res.diagnostics, d)
+		}
+	}
+
+	return res
+}
+
+// checkOperation returns a diagnostic, if the endpoint cannot be generated or depends on an unsupported component.
+func checkOperation(opts Options, doc *v3.Document, origins map[string]string, components map[string]*Diagnostic, ep endpoint) *Diagnostic {
+	var schemas []v3.Schema
+	for _, param := range ep.op.Parameters {
+		schemas = append(schemas, param.Schema)
+	}
+
+	if ep.op.RequestBody != nil {
+		for _, media := range ep.op.RequestBody.Content {
+			schemas = append(schemas, media.Schema)
+		}
+	}
+
+	for _, response := range ep.op.Responses {
+		for _, media := range response.Content {
+			schemas = append(schemas, media.Schema)
+		}
+	}
+
+	for _, schema := range schemas {
+		if dependency, ok := unsupportedDependency(doc, components, schema); ok {
+			return newDiagnostic(operationPointer(ep), "depends on the unsupported schema %s", originPointer(origins, dependency))
+		}
+	}
+
+	scratch := gen.NewGoGenFile(opts.TargetPackage, "openapi-client")
+	for _, emit := range []func() error{
+		func() error { return emitErrorTypes(opts, scratch, doc, ep) },
+		func() error { return emitSyncCall(opts, scratch, doc, "scratch", ep) },
+		func() error { return emitAsyncCall(opts, scratch, doc, "scratch", ep) },
+	} {
+		if err := emit(); err != nil {
+			return diagnosticAt(operationPointer(ep), err)
+		}
+	}
+
+	return nil
+}
+
+// originPointer returns the original pointer of a hoisted component or the pointer of the declared component.
+func originPointer(origins map[string]string, name string) string {
+	if origin, has := origins[name]; has {
+		return origin
+	}
+	return componentPointer(name)
+}
+
+// unsupportedDependency returns the name of the first unsupported component, which is referenced by the schema
+// or any of its inline sub schemas.
+func unsupportedDependency(doc *v3.Document, components map[string]*Diagnostic, schema v3.Schema) (string, bool) {
+	var dependency string
+	found := anySchema(schema, func(s v3.Schema) bool {
+		if s.Ref == nil {
+			return false
+		}
+
+		name, _ := doc.ResolveRef(*s.Ref)
+		if _, has := components[name]; has {
+			dependency = name
+			return true
+		}
+		return false
+	})
+	return dependency, found
+}
+
+// removeUnsupported removes the unsupported components and operations from the document.
+func removeUnsupported(doc *v3.Document, u unsupported) {
+	for name := range u.components {
+		delete(doc.Components.Schemas, name)
+	}
+
+	for _, ep := range u.operations {
+		item := doc.Paths[ep.path]
+		switch ep.method {
+		case "GET":
+			item.Get = nil
+		case "PUT":
+			item.Put = nil
+		case "POST":
+			item.Post = nil
+		case "DELETE":
+			item.Delete = nil
+		case "OPTIONS":
+			item.Options = nil
+		case "HEAD":
+			item.Head = nil
+		case "PATCH":
+			item.Patch = nil
+		case "TRACE":
+			item.Trace = nil
+		}
+		doc.Paths[ep.path] = item
+	}
+}
+
+// sortedEndpoints returns all endpoints of the document ordered by path and method.
+func sortedEndpoints(doc *v3.Document) []endpoint {
+	var res []endpoint
+	for path, item := range doc.Paths {
+		for method, op := range item.Map() {
+			res = append(res, endpoint{path, method, op})
+		}
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].path != res[j].path {
+			return res[i].path < res[j].path
+		}
+		return res[i].method < res[j].method
+	})
+	return res
+}
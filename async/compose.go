@@ -0,0 +1,86 @@
+// Copyright 2020 Torben Schinke
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package async
+
+import (
+	"fmt"
+	"github.com/golangee/openapi-client/internal/gen"
+	v3 "github.com/golangee/openapi/v3"
+)
+
+// mergeAllOf flattens the allOf members (and nested allOf members) of the schema and its own properties into a
+// single object schema. A property which is declared multiple times with different types is a conflict.
+func mergeAllOf(opts Options, f *gen.GoGenFile, doc *v3.Document, name string, schema v3.Schema) (v3.Schema, error) {
+	res := v3.Schema{Type: v3.Object, Description: schema.Description, Properties: map[string]v3.Schema{}}
+	origins := map[string]string{}
+	if err := mergeInto(opts, f, doc, &res, origins, name, schema, map[string]bool{}); err != nil {
+		return res, fmt.Errorf("unable to merge allOf of %s: %w", name, err)
+	}
+	return res, nil
+}
+
+// mergeInto merges the properties of schema into res. Origins tracks the member name which has declared a property
+// first and visited detects reference cycles.
+func mergeInto(opts Options, f *gen.GoGenFile, doc *v3.Document, res *v3.Schema, origins map[string]string, name string, schema v3.Schema, visited map[string]bool) error {
+	if schema.Ref != nil {
+		refName, resolved := doc.ResolveRef(*schema.Ref)
+		if resolved == nil {
+			return fmt.Errorf("unable to resolve member %s of %s", *schema.Ref, name)
+		}
+
+		if visited[refName] {
+			return fmt.Errorf("member %s of %s refers to itself", refName, name)
+		}
+		visited[refName] = true
+		defer delete(visited, refName)
+		return mergeInto(opts, f, doc, res, origins, refName, *resolved, visited)
+	}
+
+	for _, member := range schema.AllOf {
+		if err := mergeInto(opts, f, doc, res, origins, name, member, visited); err != nil {
+			return err
+		}
+	}
+
+	for _, property := range gen.SortedKeys(schema.Properties) {
+		prop := schema.Properties[property]
+		if existing, has := res.Properties[property]; has {
+			existingType, err := typeName(opts, f, doc, existing)
+			if err != nil {
+				return diagnosticAt("/properties/"+gen.EscapeToken(property), err)
+			}
+
+			newType, err := typeName(opts, f, doc, prop)
+			if err != nil {
+				return diagnosticAt("/properties/"+gen.EscapeToken(property), err)
+			}
+
+			if existingType != newType {
+				return fmt.Errorf("property '%s' is declared as %s by %s and as %s by %s", property, existingType, origins[property], newType, name)
+			}
+			continue
+		}
+
+		res.Properties[property] = prop
+		origins[property] = name
+	}
+
+	if schema.AdditionalProperties != nil && res.AdditionalProperties == nil {
+		res.AdditionalProperties = schema.AdditionalProperties
+	}
+
+	res.Required = append(res.Required, schema.Required...)
+	return nil
+}
@@ -0,0 +1,84 @@
+// Copyright 2020 Torben Schinke
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package async
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// A Diagnostic describes a construct of the specification, which cannot be generated.
+type Diagnostic struct {
+	// Pointer locates the construct as JSON pointer, like #/paths/~1users/get/responses/200.
+	Pointer string
+	// Message describes what is unsupported.
+	Message string
+}
+
+// newDiagnostic creates a diagnostic for the schema at the pointer, which may also be relative to a parent schema.
+func newDiagnostic(pointer string, format string, args ...interface{}) *Diagnostic {
+	return &Diagnostic{Pointer: pointer, Message: fmt.Sprintf(format, args...)}
+}
+
+// diagnosticAt prefixes the pointer of a relative diagnostic, like /items, with the pointer of its parent. Any
+// other error is located at the pointer itself.
+func diagnosticAt(pointer string, err error) *Diagnostic {
+	if d, ok := err.(*Diagnostic); ok {
+		return &Diagnostic{Pointer: pointer + d.Pointer, Message: d.Message}
+	}
+	return &Diagnostic{Pointer: pointer, Message: err.Error()}
+}
+
+func (d *Diagnostic) Error() string {
+	if d.Pointer == "" {
+		return d.Message
+	}
+	return d.Pointer + ": " + d.Message
+}
+
+// Diagnostics contains all constructs of the specification, which prevented the generation.
+type Diagnostics []*Diagnostic
+
+func (d Diagnostics) Error() string {
+	sb := &strings.Builder{}
+	sb.WriteString(strconv.Itoa(len(d)) + " unsupported constructs in specification")
+	for _, diagnostic := range d {
+		sb.WriteString("\n\t")
+		sb.WriteString(diagnostic.Error())
+	}
+	return sb.String()
+}
+
+// sortDiagnostics orders the diagnostics by their pointers.
+func sortDiagnostics(diagnostics Diagnostics) {
+	sort.SliceStable(diagnostics, func(i, j int) bool {
+		return diagnostics[i].Pointer < diagnostics[j].Pointer
+	})
+}
+
+// relocateDiagnostics replaces the pointers into hoisted components by the original pointers of the inline schemas.
+func relocateDiagnostics(diagnostics Diagnostics, origins map[string]string) {
+	for _, d := range diagnostics {
+		for name, origin := range origins {
+			prefix := componentPointer(name)
+			if d.Pointer == prefix || strings.HasPrefix(d.Pointer, prefix+"/") {
+				d.Pointer = origin + d.Pointer[len(prefix):]
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,139 @@
+// Copyright 2020 Torben Schinke
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package async
+
+import (
+	"fmt"
+	"github.com/golangee/openapi-client/internal/gen"
+	v3 "github.com/golangee/openapi/v3"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// isEnum checks if the schema declares a primitive with a set of allowed values.
+func isEnum(schema v3.Schema) bool {
+	if len(schema.Enum) == 0 {
+		return false
+	}
+
+	switch schema.Type {
+	case v3.String, v3.Integer, v3.Number:
+		return true
+	default:
+		return false
+	}
+}
+
+// emitEnum generates a named type with a constant for each value, a Valid and a String method. Unknown values
+// are rejected when decoding, unless Options.LenientEnums is set. A JSON null keeps the value unchanged, as for
+// any other non-pointer type. The base type is the primitive type of the schema, because a format may be mapped
+// to a type like a struct, which cannot be declared as constant.
+func emitEnum(opts Options, f *gen.GoGenFile, doc *v3.Document, name string, schema v3.Schema) error {
+	baseType, err := typeName(opts, f, doc, v3.Schema{Type: schema.Type})
+	if err != nil {
+		return err
+	}
+
+	f.Printf(gen.Comment(schema.Description))
+	f.Printf("type %s %s\n\n", name, baseType)
+
+	var constNames []string
+	usedNames := map[string]bool{}
+	f.Printf("const (\n")
+	for _, value := range schema.Enum {
+		literal, ok := enumLiteral(schema.Type, value)
+		if !ok {
+			continue // e.g. null for a nullable enum
+		}
+		constName := uniqueName(usedNames, name+enumConstName(fmt.Sprintf("%v", literal)))
+		constNames = append(constNames, constName)
+		f.Printf("%s %s = %s\n", constName, name, literal)
+	}
+	f.Printf(")\n\n")
+
+	f.Printf("// Valid checks if the value is one of the declared constants.\n")
+	f.Printf("func (e %s) Valid() bool {\n", name)
+	if len(constNames) > 0 {
+		f.Printf("switch e {\n")
+		f.Printf("case %s:\n", strings.Join(constNames, ","))
+		f.Printf("return true\n")
+		f.Printf("}\n")
+	}
+	f.Printf("return false\n")
+	f.Printf("}\n\n")
+
+	f.Printf("// String returns the value as text.\n")
+	f.Printf("func (e %s) String() string {\n", name)
+	if baseType == "string" {
+		f.Printf("return string(e)\n")
+	} else {
+		f.Printf("return %s(\"%%v\", %s(e))\n", f.ImportName("fmt", "Sprintf"), baseType)
+	}
+	f.Printf("}\n\n")
+
+	if opts.LenientEnums {
+		return nil
+	}
+
+	f.Printf("// UnmarshalJSON decodes the value and rejects any value which has not been declared.\n")
+	f.Printf("func (e *%s) UnmarshalJSON(buf []byte) error {\n", name)
+	f.Printf("if string(buf) == \"null\" {\n")
+	f.Printf("return nil\n")
+	f.Printf("}\n")
+	f.Printf("var v %s\n", baseType)
+	f.Printf("if err := %s(buf, &v); err != nil {\n", f.ImportName("encoding/json", "Unmarshal"))
+	f.Printf("return err\n")
+	f.Printf("}\n")
+	f.Printf("if !%s(v).Valid() {\n", name)
+	f.Printf("return %s(\"invalid %s value: %%v\", v)\n", f.ImportName("fmt", "Errorf"), name)
+	f.Printf("}\n")
+	f.Printf("*e = %s(v)\n", name)
+	f.Printf("return nil\n")
+	f.Printf("}\n\n")
+	return nil
+}
+
+// enumLiteral returns the go literal of an enum value or false, if it cannot be represented by the type.
+func enumLiteral(t v3.Type, value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case string:
+		if t != v3.String {
+			return "", false
+		}
+		return strconv.Quote(v), true
+	case float64:
+		if t == v3.Integer {
+			return strconv.FormatInt(int64(v), 10), true
+		}
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	default:
+		return "", false
+	}
+}
+
+// enumConstName converts an enum value like ACTIVE, in-progress or 42 into an identifier suffix like ACTIVE,
+// InProgress or 42. Negative numbers are prefixed with Minus.
+func enumConstName(value string) string {
+	if strings.HasPrefix(value, "-") {
+		return "Minus" + enumConstName(value[1:])
+	}
+
+	res := gen.Identifier(value)
+	if len(res) > 1 && res[0] == 'X' && unicode.IsDigit(rune(res[1])) {
+		return res[1:] // a leading digit is fine, because it is prefixed by the type name
+	}
+	return res
+}
@@ -0,0 +1,315 @@
+// Copyright 2020 Torben Schinke
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package async
+
+import (
+	"fmt"
+	"github.com/golangee/openapi-client/internal/gen"
+	v3 "github.com/golangee/openapi/v3"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// errorResponse is a declared 4xx, 5xx or default response with a json body.
+type errorResponse struct {
+	code      string // code is either a status like 404, a range like 4XX or default
+	typeName  string // typeName of the generated error type
+	mediaType string
+	schema    v3.Schema
+}
+
+// pointer returns the JSON pointer of the response schema, relative to its operation.
+func (e errorResponse) pointer() string {
+	return "/responses/" + gen.EscapeToken(e.code) + "/content/" + gen.EscapeToken(e.mediaType) + "/schema"
+}
+
+// caseClause returns the go switch clause which matches the status code of resp.
+func (e errorResponse) caseClause() string {
+	switch e.code {
+	case "4XX":
+		return "case resp.StatusCode >= 400 && resp.StatusCode <= 499:"
+	case "5XX":
+		return "case resp.StatusCode >= 500 && resp.StatusCode <= 599:"
+	case "default":
+		return "default:"
+	default:
+		return "case resp.StatusCode == " + e.code + ":"
+	}
+}
+
+// pickErrorResponses returns the declared error responses with json content, ordered by specificity: exact status
+// codes first, then ranges and finally the default response, if it is not already the success response.
+func pickErrorResponses(ep endpoint) []errorResponse {
+	successCode, _ := pickSuccessResponse(ep.op)
+	var exact, ranges, fallback []errorResponse
+	for _, code := range gen.SortedKeys(ep.op.Responses) {
+		response := ep.op.Responses[code]
+		mediaType := pickMediaType(response.Content)
+		if !isJsonMediaType(mediaType) {
+			continue
+		}
+
+		res := errorResponse{code: code, mediaType: mediaType, schema: response.Content[mediaType].Schema}
+		prefix := methodName(ep)
+		switch {
+		case code == "4XX":
+			res.typeName = prefix + "ClientError"
+			ranges = append(ranges, res)
+		case code == "5XX":
+			res.typeName = prefix + "ServerError"
+			ranges = append(ranges, res)
+		case code == "default" && successCode != "default":
+			res.typeName = prefix + "DefaultError"
+			fallback = append(fallback, res)
+		case len(code) == 3 && (code[0] == '4' || code[0] == '5'):
+			res.typeName = prefix + statusName(code) + "Error"
+			exact = append(exact, res)
+		}
+	}
+
+	return append(append(exact, ranges...), fallback...)
+}
+
+// statusName returns the status text as identifier, e.g. NotFound for 404 or Status499 if unknown.
+func statusName(code string) string {
+	status, err := strconv.Atoi(code)
+	if err == nil && http.StatusText(status) != "" {
+		return gen.Identifier(http.StatusText(status))
+	}
+	return "Status" + code
+}
+
+// errorParserName returns the name of the generated function which decodes the error responses of the endpoint or
+// nil, if it has not declared any.
+func errorParserName(ep endpoint) string {
+	if len(pickErrorResponses(ep)) == 0 {
+		return "nil"
+	}
+	return "parse" + methodName(ep) + "Error"
+}
+
+// emitErrorTypes generates a type for each declared error response of the endpoint and a function which picks and
+// decodes the matching one.
+func emitErrorTypes(opts Options, f *gen.GoGenFile, doc *v3.Document, ep endpoint) error {
+	responses := pickErrorResponses(ep)
+	if len(responses) == 0 {
+		return nil
+	}
+
+	for _, res := range responses {
+		bodyType, err := typeName(opts, f, doc, res.schema)
+		if err != nil {
+			return diagnosticAt(res.pointer(), err)
+		}
+
+		f.Printf("// %s is returned by %s for the %s response.\n", res.typeName, methodName(ep), res.code)
+		f.Printf("type %s struct {\n", res.typeName)
+		f.Printf("StatusCode int\n")
+		f.Printf("Header %s\n", f.ImportName("net/http", "Header"))
+		f.Printf("RawBody []byte // RawBody is at most maxErrorBodySize long\n")
+		f.Printf("Body %s\n", bodyType)
+		f.Printf("}\n\n")
+		f.Printf("// Error returns the status code and its text.\n")
+		f.Printf("func (e *%s) Error() string {\n", res.typeName)
+		f.Printf("return %s(\"%%d %%s\", e.StatusCode, %s(e.StatusCode))\n", f.ImportName("fmt", "Sprintf"), f.ImportName("net/http", "StatusText"))
+		f.Printf("}\n\n")
+	}
+
+	f.Printf("// %s decodes the declared error responses of %s. Returns nil for undeclared status codes or\n", errorParserName(ep), methodName(ep))
+	f.Printf("// unexpected content.\n")
+	f.Printf("func %s(resp *%s, buf []byte) error {\n", errorParserName(ep), f.ImportName("net/http", "Response"))
+	f.Printf("switch {\n")
+	for _, res := range responses {
+		f.Printf("%s\n", res.caseClause())
+		f.Printf("e := &%s{StatusCode: resp.StatusCode, Header: resp.Header, RawBody: buf}\n", res.typeName)
+		f.Printf("if err := %s(buf, &e.Body); err != nil {\n", f.ImportName("encoding/json", "Unmarshal"))
+		f.Printf("return nil\n")
+		f.Printf("}\n")
+		f.Printf("return e\n")
+	}
+	f.Printf("}\n")
+	if responses[len(responses)-1].code != "default" {
+		f.Printf("return nil\n")
+	}
+	f.Printf("}\n\n")
+	return nil
+}
+
+// builtinErrorModelName returns the name of the generated error model type or the empty string, if the model is
+// a referenced type.
+func builtinErrorModelName(opts Options) string {
+	switch opts.ErrorModel {
+	case "", GolangeeErrorModel:
+		return "Error"
+	case ProblemErrorModel:
+		return "Problem"
+	default:
+		return ""
+	}
+}
+
+// builtinErrorModelProperties contains the json properties of the generated error model types.
+var builtinErrorModelProperties = map[string][]string{
+	"Error":   {"id", "message", "localizedMessage", "causedBy", "type", "details"},
+	"Problem": {"type", "title", "status", "detail", "instance"},
+}
+
+// checkErrorModelClash fails, if a component has the name of the generated error model but declares other
+// properties. A component which only declares properties of the model describes the model itself, like the Error
+// of a golangee server, and is replaced by the generated type.
+func checkErrorModelClash(opts Options, doc *v3.Document) error {
+	name := builtinErrorModelName(opts)
+	if name == "" || doc.Components == nil {
+		return nil
+	}
+
+	schema, has := doc.Components.Schemas[name]
+	if !has {
+		return nil
+	}
+
+	if !isObject(schema) || len(schema.AllOf) > 0 || isUnion(schema) {
+		return newDiagnostic(componentPointer(name), "clashes with the generated error model %s, rename the component or choose another error model", name)
+	}
+
+	known := map[string]bool{}
+	for _, property := range builtinErrorModelProperties[name] {
+		known[property] = true
+	}
+
+	for _, property := range gen.SortedKeys(schema.Properties) {
+		if !known[property] {
+			return newDiagnostic(componentPointer(name), "clashes with the generated error model %s, which has no property '%s', rename the component or choose another error model", name, property)
+		}
+	}
+	return nil
+}
+
+// emitErrorModel generates the ResponseError and the parseErrorModel function which decodes the configured error
+// model.
+func emitErrorModel(opts Options, f *gen.GoGenFile) error {
+	f.Printf("%s", responseErrorStub)
+	switch opts.ErrorModel {
+	case "", GolangeeErrorModel:
+		f.Printf("%s\n\n", errTypeStub)
+		f.Printf(parseErrorModelStub, "Error")
+	case ProblemErrorModel:
+		f.Printf("%s", problemTypeStub)
+		f.Printf(parseErrorModelStub, "Problem")
+	default:
+		tuple := strings.Split(opts.ErrorModel, "#")
+		if len(tuple) != 2 || tuple[0] == "" || tuple[1] == "" {
+			return fmt.Errorf("invalid error model '%s': expected a type reference like github.com/myproject/errors#MyError", opts.ErrorModel)
+		}
+		f.Printf(parseErrorModelStub, f.ImportName(tuple[0], tuple[1]))
+	}
+	return nil
+}
+
+const responseErrorStub = `// maxErrorBodySize limits the amount of bytes which are read from an error response.
+const maxErrorBodySize = 64 * 1024
+
+// ResponseError is returned for each error response which has not been declared by the operation. It keeps
+// the status code, the header and the raw body and unwraps to the decoded error model, if possible.
+type ResponseError struct {
+	StatusCode int
+	Header     http.Header
+	RawBody    []byte // RawBody is at most maxErrorBodySize long
+	Cause      error  // Cause is either the decoded error model or the reason why decoding failed
+}
+
+// Error returns the status code, its text and the message of the cause. If the body could not be decoded at all,
+// e.g. for a html page from a proxy, the beginning of the body is returned instead.
+func (e *ResponseError) Error() string {
+	msg := fmt.Sprintf("%d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	if e.Cause == nil || errors.As(e.Cause, &syntaxErr) || errors.As(e.Cause, &typeErr) {
+		if snippet := bodySnippet(e.RawBody); snippet != "" {
+			msg += ": " + snippet
+		}
+		return msg
+	}
+	return msg + ": " + e.Cause.Error()
+}
+
+// Unwrap returns the cause
+func (e *ResponseError) Unwrap() error {
+	return e.Cause
+}
+
+// bodySnippet returns the beginning of the body as a single line of text.
+func bodySnippet(buf []byte) string {
+	const maxLen = 200
+	text := strings.Join(strings.Fields(string(buf)), " ")
+	if runes := []rune(text); len(runes) > maxLen {
+		return string(runes[:maxLen]) + "..."
+	}
+	return text
+}
+
+`
+
+const parseErrorModelStub = `// parseErrorModel tries to decode the body as json into the error model.
+func parseErrorModel(resp *http.Response, buf []byte) error {
+	res := &%[1]s{}
+	if err := json.Unmarshal(buf, res); err != nil {
+		return err
+	}
+	return res
+}
+
+`
+
+const problemTypeStub = `// Problem details an error response according to RFC 7807.
+type Problem struct {
+	Type       string                     ` + "`json:\"type,omitempty\"`" + `     // Type is an URI which identifies the problem type
+	Title      string                     ` + "`json:\"title,omitempty\"`" + `    // Title is a short summary of the problem type
+	Status     int                        ` + "`json:\"status,omitempty\"`" + `   // Status is the http status code of the origin server
+	Detail     string                     ` + "`json:\"detail,omitempty\"`" + `   // Detail explains this occurrence of the problem
+	Instance   string                     ` + "`json:\"instance,omitempty\"`" + ` // Instance is an URI which identifies this occurrence
+	Extensions map[string]json.RawMessage ` + "`json:\"-\"`" + `                // Extensions contains any additional members
+}
+
+// Error returns the title and the detail
+func (p *Problem) Error() string {
+	if p.Detail == "" {
+		return p.Title
+	}
+	return p.Title + ": " + p.Detail
+}
+
+// UnmarshalJSON decodes the standard members and collects all others as extensions.
+func (p *Problem) UnmarshalJSON(buf []byte) error {
+	type problem Problem
+	if err := json.Unmarshal(buf, (*problem)(p)); err != nil {
+		return err
+	}
+
+	ext := map[string]json.RawMessage{}
+	if err := json.Unmarshal(buf, &ext); err != nil {
+		return err
+	}
+	for _, member := range []string{"type", "title", "status", "detail", "instance"} {
+		delete(ext, member)
+	}
+	if len(ext) > 0 {
+		p.Extensions = ext
+	}
+	return nil
+}
+
+`
@@ -0,0 +1,230 @@
+// Copyright 2020 Torben Schinke
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package async
+
+import (
+	"fmt"
+	"github.com/golangee/openapi-client/internal/gen"
+	v3 "github.com/golangee/openapi/v3"
+	"strings"
+)
+
+// dateTypeName is the name of the generated civil date type.
+const dateTypeName = "Date"
+
+// formatMapping declares the Go type of a format, which only applies to schemas of the given type.
+type formatMapping struct {
+	schemaType v3.Type
+	goType     string
+}
+
+// builtinFormats maps the well known formats to their Go types. Types from other packages are declared as type
+// references like time#Time.
+var builtinFormats = map[string]formatMapping{
+	"int32":     {v3.Integer, "int32"},
+	"int64":     {v3.Integer, "int64"},
+	"float":     {v3.Number, "float32"},
+	"double":    {v3.Number, "float64"},
+	"decimal":   {v3.Number, "encoding/json#Number"},
+	"date-time": {v3.String, "time#Time"},
+	"date":      {v3.String, dateTypeName},
+	"uuid":      {v3.String, "string"},
+	"byte":      {v3.String, "[]byte"},
+	"binary":    {v3.String, "[]byte"},
+}
+
+// formatTypeName returns the Go type of a primitive schema with a format or false, if the format is unknown or
+// does not apply to the schema type. Options.Formats take precedence over the built-in formats.
+func formatTypeName(opts Options, f *gen.GoGenFile, schema v3.Schema) (string, bool) {
+	if schema.Format == "" || schema.Ref != nil || schema.Type == v3.Object || schema.Type == v3.Array {
+		return "", false
+	}
+
+	if goType, has := opts.Formats[schema.Format]; has {
+		return goTypeName(f, goType), true
+	}
+
+	mapping, has := builtinFormats[schema.Format]
+	if !has || mapping.schemaType != schema.Type {
+		return "", false
+	}
+
+	return goTypeName(f, mapping.goType), true
+}
+
+// isBinaryBody checks if the schema of a request body is binary as a whole, which is streamed from an io.Reader
+// instead of being buffered as []byte, unless Options.Formats declares another type.
+func isBinaryBody(opts Options, schema v3.Schema) bool {
+	if _, has := opts.Formats["binary"]; has {
+		return false
+	}
+	return schema.Ref == nil && schema.Type == v3.String && schema.Format == "binary"
+}
+
+// goTypeName imports the package of a type reference like github.com/google/uuid#UUID and returns its qualified
+// name. Anything else, like int64 or []byte, is returned as is.
+func goTypeName(f *gen.GoGenFile, goType string) string {
+	idx := strings.LastIndex(goType, "#")
+	if idx < 0 {
+		return goType
+	}
+
+	return f.ImportName(goType[:idx], goType[idx+1:])
+}
+
+// validateFormats checks the type references of Options.Formats.
+func validateFormats(opts Options) error {
+	for _, format := range gen.SortedKeys(opts.Formats) {
+		goType := opts.Formats[format]
+		if goType == "" || strings.HasPrefix(goType, "#") || strings.HasSuffix(goType, "#") {
+			return fmt.Errorf("invalid type '%s' for format '%s': expected a Go type like int64 or a type reference like github.com/google/uuid#UUID", goType, format)
+		}
+	}
+	return nil
+}
+
+// usesDateType checks if any schema of the document is mapped to the generated civil date type.
+func usesDateType(opts Options, doc *v3.Document) bool {
+	if goType, has := opts.Formats[string(v3.Date)]; has && goType != dateTypeName {
+		return false
+	}
+
+	isDate := func(schema v3.Schema) bool {
+		return schema.Type == v3.String && schema.Format == string(v3.Date)
+	}
+
+	if doc.Components != nil {
+		for _, schema := range doc.Components.Schemas {
+			if anySchema(schema, isDate) {
+				return true
+			}
+		}
+	}
+
+	for _, path := range doc.Paths {
+		for _, op := range path.Map() {
+			for _, param := range op.Parameters {
+				if anySchema(param.Schema, isDate) {
+					return true
+				}
+			}
+
+			if op.RequestBody != nil {
+				for _, media := range op.RequestBody.Content {
+					if anySchema(media.Schema, isDate) {
+						return true
+					}
+				}
+			}
+
+			for _, response := range op.Responses {
+				for _, media := range response.Content {
+					if anySchema(media.Schema, isDate) {
+						return true
+					}
+				}
+			}
+		}
+	}
+
+	return false
+}
+
+// anySchema checks if the predicate matches the schema or any of its inline sub schemas. References are not
+// followed.
+func anySchema(schema v3.Schema, predicate func(v3.Schema) bool) bool {
+	if predicate(schema) {
+		return true
+	}
+
+	for _, prop := range schema.Properties {
+		if anySchema(prop, predicate) {
+			return true
+		}
+	}
+
+	if schema.Items != nil && schema.Items.Schema != nil && anySchema(*schema.Items.Schema, predicate) {
+		return true
+	}
+
+	if schema.AdditionalProperties != nil && schema.AdditionalProperties.Schema != nil &&
+		anySchema(*schema.AdditionalProperties.Schema, predicate) {
+		return true
+	}
+
+	for _, list := range [][]v3.Schema{schema.OneOf, schema.AnyOf, schema.AllOf} {
+		for _, s := range list {
+			if anySchema(s, predicate) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// emitDateType generates the civil date type, unless a component with the same name has been declared.
+func emitDateType(opts Options, f *gen.GoGenFile, doc *v3.Document) error {
+	if !usesDateType(opts, doc) {
+		return nil
+	}
+
+	if doc.Components != nil {
+		if _, has := doc.Components.Schemas[dateTypeName]; has {
+			return fmt.Errorf("the component %s clashes with the generated type for format 'date': declare another type in Options.Formats", dateTypeName)
+		}
+	}
+
+	f.ImportName("time", "Time")
+	f.Printf("%s", dateTypeStub)
+	return nil
+}
+
+const dateTypeStub = `
+// Date is a civil date without a time or location, encoded as RFC 3339 full-date like 2006-01-02.
+type Date struct {
+	Year  int
+	Month time.Month
+	Day   int
+}
+
+// NewDate returns the civil date of the given time in its location.
+func NewDate(t time.Time) Date {
+	var d Date
+	d.Year, d.Month, d.Day = t.Date()
+	return d
+}
+
+// String returns the date as RFC 3339 full-date.
+func (d Date) String() string {
+	return fmt.Sprintf("%04d-%02d-%02d", d.Year, d.Month, d.Day)
+}
+
+// MarshalText encodes the date as RFC 3339 full-date.
+func (d Date) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText parses a RFC 3339 full-date.
+func (d *Date) UnmarshalText(buf []byte) error {
+	t, err := time.Parse("2006-01-02", string(buf))
+	if err != nil {
+		return fmt.Errorf("invalid date '%s': %w", string(buf), err)
+	}
+	*d = NewDate(t)
+	return nil
+}
+
+`
@@ -17,30 +17,149 @@ package async
 import (
 	"fmt"
 	"github.com/golangee/openapi-client/internal/gen"
+	"github.com/golangee/openapi-client/internal/load"
 	v3 "github.com/golangee/openapi/v3"
+	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 )
 
+const (
+	// GolangeeErrorModel decodes undeclared error responses into the nested Error type of golangee/http.
+	GolangeeErrorModel = "golangee"
+	// ProblemErrorModel decodes undeclared error responses into a Problem according to RFC 7807, which is the
+	// format of application/problem+json.
+	ProblemErrorModel = "problem"
+)
+
 // Options to use for generating a new client
 type Options struct {
-	TargetDir     string
+	// TargetDir is the directory of the generated openapiclient.gen.go file, either absolute or relative to the
+	// working directory, which is used if it is empty.
+	TargetDir string
+	// Output receives the generated source instead of a file in the TargetDir, e.g. to keep it in memory.
+	Output        io.Writer
 	TargetPackage string
 	// UseReferences contains names of x-ee.type like github.com/golangee/uuid#UUID will be used instead of generated.
 	// This is especially required for types with a custom serialization format which OpenAPI does not support to
 	// express (like UUIDs which must be either strings (as specified) or byte arrays (as base64) - but the
 	// information that it is indeed a UUID is lost).
 	UseReferences []string
+	// TypeMappings replace the generated types of the matching schemas by existing Go types, e.g. to reuse the
+	// domain types of a shared module. They are more flexible than UseReferences, which are still applied after
+	// the type mappings.
+	TypeMappings []TypeMapping
+	// OptionalPointers renders optional and nullable properties as pointer types, so that an absent property can
+	// be distinguished from its zero value (e.g. when patching resources). Required properties stay value types.
+	OptionalPointers bool
+	// ErrorModel determines the type into which any error response is decoded, which has not been declared by
+	// the operation. It is either GolangeeErrorModel (the default), ProblemErrorModel or a reference to a type
+	// like github.com/myproject/errors#MyError, whose pointer must implement the error interface. A component
+	// named like the generated Error or Problem is replaced by it, if it only declares properties of the model,
+	// otherwise the generation fails.
+	ErrorModel string
+	// LenientEnums accepts unknown enum values when decoding, so that a client keeps working if the server adds
+	// new values. By default, unknown values are rejected.
+	LenientEnums bool
+	// Formats maps a schema format like uuid or date-time to a Go type, which is either a builtin type like
+	// string or a type reference like github.com/google/uuid#UUID. It takes precedence over the built-in formats,
+	// e.g. int64 to int64, date-time to time.Time, date to a generated civil Date, byte to []byte and binary to
+	// []byte. A request body which is binary as a whole is streamed from an io.Reader instead.
+	Formats map[string]string
+	// SkipUnsupported leaves out all schemas and operations which cannot be generated, instead of failing with
+	// the Diagnostics. Schemas and operations which depend on left out schemas are left out as well.
+	SkipUnsupported bool
+	// OnSkip is called for each construct which has been left out due to SkipUnsupported.
+	OnSkip func(d *Diagnostic)
+}
+
+// TypeMapping replaces the generated type of matching schemas by an existing Go type. A schema is matched by its
+// JSON pointer, its component name, its x-ee.type or by its type and format, in this order of precedence.
+type TypeMapping struct {
+	// Pointer matches the schema at the JSON pointer, like #/components/schemas/User/properties/id.
+	Pointer string
+	// Component matches the component schema of the given name and therefore all references to it.
+	Component string
+	// XType matches all schemas with the given x-ee.type.
+	XType string
+	// Type and Format match all primitive schemas, like string and uuid. An empty Format only matches schemas
+	// without a format.
+	Type   string
+	Format string
+	// GoType is either a builtin type like int64 or a type reference like github.com/myproject/domain#UserID.
+	GoType string
+	// Marshal and Unmarshal optionally refer to functions like github.com/myproject/domain#MarshalUserID with
+	// the signatures func(T) ([]byte, error) and func([]byte) (T, error), which convert GoType from and to its
+	// json representation. The matching schemas are then represented by a generated adapter type like
+	// UserIDAdapter, which wraps GoType in its Value field.
+	Marshal   string
+	Unmarshal string
 }
 
-// Generates determines the root of the module and applies the options to generate a new client from the spec.
+// Generates applies the options to generate a new client from the spec into the TargetDir or the Output.
+// The spec is either in JSON or in YAML format, which is detected by its content. References into other files
+// are resolved relative to the working directory.
 func Generate(spec []byte, opts Options) error {
-	doc, err := v3.FromJson(spec)
+	return generate("", spec, opts)
+}
+
+// GenerateFile is like Generate but reads the spec from the file, whose format is detected by its extension.
+// References into other files are resolved relative to the referring file.
+func GenerateFile(fname string, opts Options) error {
+	spec, err := ioutil.ReadFile(fname)
+	if err != nil {
+		return fmt.Errorf("unable to read document: %w", err)
+	}
+
+	return generate(fname, spec, opts)
+}
+
+func generate(name string, spec []byte, opts Options) error {
+	buf, err := load.Bundle(name, spec)
 	if err != nil {
 		return fmt.Errorf("unable to parse document: %w", err)
 	}
 
+	doc, err := v3.FromJson(buf)
+	if err != nil {
+		return fmt.Errorf("unable to parse document: %w", err)
+	}
+
+	if err := validateFormats(opts); err != nil {
+		return err
+	}
+
+	if err := validateTypeMappings(opts); err != nil {
+		return err
+	}
+
+	if err := checkErrorModelClash(opts, doc); err != nil {
+		return err
+	}
+
+	if err := checkTypeMappings(opts, doc); err != nil {
+		return err
+	}
+
+	origins := hoistInlineSchemas(opts, doc)
+	opts.TypeMappings = resolveTypeMappings(opts, origins)
+	unsupported := checkSupported(opts, doc, origins)
+	if len(unsupported.diagnostics) > 0 {
+		relocateDiagnostics(unsupported.diagnostics, origins)
+		sortDiagnostics(unsupported.diagnostics)
+		if !opts.SkipUnsupported {
+			return unsupported.diagnostics
+		}
+
+		removeUnsupported(doc, unsupported)
+		for _, d := range unsupported.diagnostics {
+			if opts.OnSkip != nil {
+				opts.OnSkip(d)
+			}
+		}
+	}
+
 	file := gen.NewGoGenFile(opts.TargetPackage, "openapi-client")
 
 	err = emitTypes(opts, file, doc)
@@ -48,7 +167,14 @@ func Generate(spec []byte, opts Options) error {
 		return fmt.Errorf("unable to emit types: %w", err)
 	}
 
-	parentType, err := emitApiRoot(file, doc)
+	err = emitDateType(opts, file, doc)
+	if err != nil {
+		return fmt.Errorf("unable to emit types: %w", err)
+	}
+
+	emitTypeAdapters(opts, file)
+
+	parentType, err := emitApiRoot(opts, file, doc)
 	if err != nil {
 		return fmt.Errorf("unable to emit api root: %w", err)
 	}
@@ -58,16 +184,27 @@ func Generate(spec []byte, opts Options) error {
 		return fmt.Errorf("unable to emit call groups: %w", err)
 	}
 
-	fmt.Println(file.FormatString())
-
-	dir, err := gen.ModRootDir()
+	src, err := file.FormatString()
 	if err != nil {
 		return err
 	}
 
-	fname := filepath.Join(dir, opts.TargetDir, "openapiclient.gen.go")
-	if err := ioutil.WriteFile(fname, []byte(file.FormatString()), os.ModePerm); err != nil {
-		return err
+	if opts.Output != nil {
+		if _, err := io.WriteString(opts.Output, src); err != nil {
+			return fmt.Errorf("unable to write source: %w", err)
+		}
+		return nil
+	}
+
+	if opts.TargetDir != "" {
+		if err := os.MkdirAll(opts.TargetDir, 0755); err != nil {
+			return fmt.Errorf("unable to create target directory: %w", err)
+		}
+	}
+
+	fname := filepath.Join(opts.TargetDir, "openapiclient.gen.go")
+	if err := ioutil.WriteFile(fname, []byte(src), 0644); err != nil {
+		return fmt.Errorf("unable to write source: %w", err)
 	}
 
 	return nil
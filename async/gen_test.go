@@ -14,18 +14,2925 @@
 
 package async
 
-import "testing"
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/golangee/openapi-client/internal/gen"
+	v3 "github.com/golangee/openapi/v3"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
 
 func TestGenerate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "openapi-client")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	err = Generate([]byte(spec), Options{
+		TargetDir:     filepath.Join(dir, "client"),
+		TargetPackage: "blub",
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stat, err := os.Stat(filepath.Join(dir, "client", "openapiclient.gen.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stat.Mode().Perm()&^0644 != 0 {
+		t.Fatalf("expected the file to be written without execute permissions but got %v", stat.Mode())
+	}
+}
+
+func TestGenerateOutput(t *testing.T) {
+	buf := &bytes.Buffer{}
+	err := Generate([]byte(spec), Options{
+		Output:        buf,
+		TargetPackage: "blub",
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(buf.String(), "// Code generated by openapi-client. DO NOT EDIT.") {
+		t.Fatalf("expected the generated source but got\n%s", buf.String())
+	}
+}
+
+func TestInvalidErrorModel(t *testing.T) {
 	err := Generate([]byte(spec), Options{
 		TargetDir:     "",
 		TargetPackage: "blub",
+		ErrorModel:    "MyError",
 	})
 
-	if err != nil {
-		t.Fatal(err)
-	}
+	if err == nil {
+		t.Fatal("expected an error for an error model without import path")
+	}
+}
+
+func TestErrorModelClash(t *testing.T) {
+	problem := strings.Replace(spec, `"Status":{`, `"Problem":{
+            "type":"object",
+            "properties":{
+               "title":{
+                  "type":"string"
+               },
+               "code":{
+                  "type":"integer"
+               }
+            }
+         },
+         "Status":{`, 1)
+	err := Generate([]byte(problem), Options{TargetPackage: "blub", Output: &bytes.Buffer{}, ErrorModel: ProblemErrorModel})
+	if err == nil || !strings.Contains(err.Error(), "#/components/schemas/Problem: clashes with the generated error model Problem, which has no property 'code'") {
+		t.Fatalf("expected a clash with the problem model but got %v", err)
+	}
+
+	compatible := strings.Replace(problem, `"code":{`, `"status":{`, 1)
+	buildClient(t, compatible, Options{ErrorModel: ProblemErrorModel}, "", nil)
+
+	err = Generate([]byte(compatible), Options{TargetPackage: "blub", Output: &bytes.Buffer{}})
+	if err != nil {
+		t.Fatalf("expected the Problem component to be generated with the default model but got %v", err)
+	}
+
+	golangee := strings.Replace(spec, `"Status":{`, `"Error":{
+            "type":"string"
+         },
+         "Status":{`, 1)
+	err = Generate([]byte(golangee), Options{TargetPackage: "blub", Output: &bytes.Buffer{}})
+	if err == nil || !strings.Contains(err.Error(), "#/components/schemas/Error: clashes with the generated error model Error") {
+		t.Fatalf("expected a clash with the golangee model but got %v", err)
+	}
+}
+
+func TestTypes(t *testing.T) {
+	tests := []struct {
+		name       string
+		spec       string
+		opts       Options
+		stubs      map[string]string
+		expected   []string
+		unexpected []string
+		roundTrip  string
+	}{
+		{
+			name: "property names",
+			spec: propertyNamesSpec,
+			expected: []string{
+				"CreatedAt string `json:\"createdAt\"`",
+				"UserId string `json:\"user_id,omitempty\"`",
+				"XCount int `json:\"x-count,omitempty\"`",
+				"Type2 string `json:\"type,omitempty\"`",
+			},
+			roundTrip: propertyNamesRoundTrip,
+		},
+		{
+			name: "optional pointers",
+			spec: optionalSpec,
+			opts: Options{OptionalPointers: true},
+			expected: []string{
+				"Name string `json:\"name\"`",
+				"Nick *string `json:\"nick\"`",
+				"Age *int `json:\"age,omitempty\"`",
+				"Tags []string `json:\"tags,omitempty\"`",
+			},
+		},
+		{
+			name: "enums",
+			spec: enumSpec,
+			expected: []string{
+				"type State string",
+				"StateInProgress State = \"in-progress\"",
+				"func (e *State) UnmarshalJSON(buf []byte) error",
+				"type TaskPriority int",
+				"TaskPriorityMinus1 TaskPriority = -1",
+				"Priority TaskPriority `json:\"priority,omitempty\"`",
+				"type Holiday string",
+			},
+			roundTrip: strictEnumRoundTrip,
+		},
+		{
+			name:      "lenient enums",
+			spec:      enumSpec,
+			opts:      Options{LenientEnums: true},
+			roundTrip: lenientEnumRoundTrip,
+		},
+		{
+			name: "unions",
+			spec: unionSpec,
+			expected: []string{
+				"func NewPetFromCat(v Cat) Pet",
+				"func (u Pet) AsDog() (Dog, bool)",
+				"case \"cat\", \"kitty\":",
+				"case \"Dog\":",
+				"type OwnerContact struct",
+				"func (u OwnerContact) AsString() (string, bool)",
+			},
+			roundTrip: unionRoundTrip,
+		},
+		{
+			name: "allOf",
+			spec: allOfSpec,
+			opts: Options{SkipUnsupported: true},
+			expected: []string{
+				"type Admin struct",
+				"Name string `json:\"name\"`",
+				"Level int `json:\"level,omitempty\"`",
+			},
+			unexpected: []string{"type Broken"},
+		},
+		{
+			name:       "nullable allOf",
+			spec:       nullableAllOfSpec,
+			opts:       Options{OptionalPointers: true},
+			expected:   []string{"Customer *Customer `json:\"customer\"`", "Billing *Customer `json:\"billing,omitempty\"`"},
+			unexpected: []string{"type OrderCustomer", "type OrderBilling"},
+		},
+		{
+			name:       "allOf reference",
+			spec:       nullableAllOfSpec,
+			expected:   []string{"Customer Customer `json:\"customer\"`", "Billing Customer `json:\"billing,omitempty\"`"},
+			unexpected: []string{"type OrderCustomer", "type OrderBilling"},
+		},
+		{
+			name: "additional properties",
+			spec: mapSpec,
+			expected: []string{
+				"type Labels map[string]string",
+				"type Anything map[string]json.RawMessage",
+				"AdditionalProperties map[string]int `json:\"-\"`",
+				"func (t *Tagged) UnmarshalJSON(buf []byte) error",
+			},
+			roundTrip: mapRoundTrip,
+		},
+		{
+			name: "inline objects",
+			spec: inlineSpec,
+			expected: []string{
+				"Address UserAddress `json:\"address,omitempty\"`",
+				"type UserAddress struct",
+				"type UsersResponseItem struct",
+				"type PostUsersRequest struct",
+				"f func(res []UsersResponseItem, err error)",
+				"body PostUsersRequest",
+			},
+		},
+		{
+			name:  "formats",
+			spec:  formatSpec,
+			opts:  Options{Formats: map[string]string{"uuid": "github.com/google/uuid#UUID"}},
+			stubs: map[string]string{"github.com/google/uuid": "package uuid\n\ntype UUID [16]byte\n"},
+			expected: []string{
+				"Id uuid.UUID `json:\"id\"`",
+				"Count int64 `json:\"count\"`",
+				"Ratio float32 `json:\"ratio\"`",
+				"CreatedAt time.Time `json:\"createdAt\"`",
+				"Birthday Date `json:\"birthday\"`",
+				"Avatar []byte `json:\"avatar\"`",
+				"func (d *Date) UnmarshalText(buf []byte) error",
+			},
+			roundTrip: dateRoundTrip,
+		},
+		{
+			name: "binary",
+			spec: binarySpec,
+			expected: []string{
+				"Content []byte `json:\"content\"`",
+				"body io.Reader",
+			},
+			unexpected: []string{"Content io.Reader"},
+		},
+		{
+			name: "type mappings",
+			spec: mappingSpec,
+			opts: Options{
+				UseReferences: []string{"github.com/golangee/uuid#UUID"},
+				TypeMappings: []TypeMapping{
+					{Component: "Money", GoType: "github.com/myproject/domain#Money"},
+					{Pointer: "#/components/schemas/Order/properties/shipping", GoType: "github.com/myproject/domain#Address"},
+					{XType: "color", GoType: "image/color#RGBA", Marshal: "github.com/myproject/domain#MarshalColor",
+						Unmarshal: "github.com/myproject/domain#UnmarshalColor"},
+					{Type: "string", Format: "email", GoType: "net/mail#Address"},
+					{Pointer: "#/components/schemas/Order/properties/price", GoType: "github.com/myproject/domain#Money",
+						Marshal: "github.com/myproject/domain#MarshalMoney", Unmarshal: "github.com/myproject/domain#UnmarshalMoney"},
+					{Pointer: "#/paths/~1orders/get/responses/200/content/application~1json/schema/properties/count", GoType: "int64"},
+				},
+			},
+			stubs: map[string]string{
+				"github.com/golangee/uuid":    "package uuid\n\ntype UUID [16]byte\n",
+				"github.com/myproject/domain": domainStub,
+			},
+			expected: []string{
+				"Id uuid.UUID `json:\"id\"`",
+				"Total domain.Money `json:\"total\"`",
+				"Shipping domain.Address `json:\"shipping\"`",
+				"Color RGBAAdapter `json:\"color\"`",
+				"Contact mail.Address `json:\"contact\"`",
+				"return domain.MarshalColor(a.Value)",
+				"Price MoneyAdapter `json:\"price\"`",
+				"Count int64 `json:\"count,omitempty\"`",
+			},
+			unexpected: []string{"type Money struct", "type OrderShipping"},
+		},
+		{
+			name: "named types",
+			spec: namedSpec,
+			expected: []string{
+				"// Id identifies a resource.\ntype Id string",
+				"type Tags []TagsItem",
+				"type TagsItem struct",
+				"type Active bool",
+				"type Created = time.Time",
+				"type Owner = Id",
+			},
+		},
+		{
+			name: "recursive schemas",
+			spec: recursiveSpec,
+			expected: []string{
+				"Parent *Category `json:\"parent\"`",
+				"Children []Node `json:\"children\"`",
+				"Next *Node `json:\"next\"`",
+				"B *B `json:\"b\"`",
+				"A *A `json:\"a\"`",
+				"Leaf Leaf `json:\"leaf\"`",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := buildClient(t, tt.spec, tt.opts, tt.roundTrip, tt.stubs)
+			for _, expected := range tt.expected {
+				if !strings.Contains(src, expected) {
+					t.Fatalf("expected %s in\n%s", expected, src)
+				}
+			}
+
+			for _, unexpected := range tt.unexpected {
+				if strings.Contains(src, unexpected) {
+					t.Fatalf("unexpected %s in\n%s", unexpected, src)
+				}
+			}
+		})
+	}
+}
+
+func TestAllOfConflict(t *testing.T) {
+	err := Generate([]byte(allOfSpec), Options{TargetPackage: "blub", Output: &bytes.Buffer{}})
+	if err == nil || !strings.Contains(err.Error(), "'name'") {
+		t.Fatalf("expected a conflict of property name but got %v", err)
+	}
+}
+
+func TestInvalidTypeReferences(t *testing.T) {
+	if err := validateFormats(Options{Formats: map[string]string{"uuid": "github.com/google/uuid#"}}); err == nil {
+		t.Fatal("expected an error for an incomplete type reference")
+	}
+
+	doc, err := v3.FromJson([]byte(mappingSpec))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = checkTypeMappings(Options{TypeMappings: []TypeMapping{{Component: "Missing", GoType: "int"}}}, doc)
+	if err == nil {
+		t.Fatal("expected an error for a mapping without a matching schema")
+	}
+}
+
+func TestDiagnostics(t *testing.T) {
+	err := Generate([]byte(unsupportedSpec), Options{TargetPackage: "blub"})
+	var diagnostics Diagnostics
+	if !errors.As(err, &diagnostics) {
+		t.Fatalf("expected diagnostics but got %v", err)
+	}
+
+	expected := []string{
+		"#/components/schemas/Holder: depends on the unsupported schema #/components/schemas/Holder/properties/inner",
+		"#/components/schemas/Holder/properties/inner/properties/x: unsupported type 'tuple'",
+		"#/paths/~1holders/get: depends on the unsupported schema #/components/schemas/Holder",
+		"#/paths/~1items/get/parameters/0/schema: array without items",
+	}
+	if len(diagnostics) != len(expected) {
+		t.Fatalf("expected %d diagnostics but got %v", len(expected), diagnostics)
+	}
+
+	for i, d := range diagnostics {
+		if d.Error() != expected[i] {
+			t.Fatalf("expected %s but got %s", expected[i], d.Error())
+		}
+	}
+
+	doc, err := v3.FromJson([]byte(unsupportedSpec))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hoistInlineSchemas(Options{}, doc)
+	unsupported := checkSupported(Options{}, doc, nil)
+	removeUnsupported(doc, unsupported)
+	if len(doc.Paths["/holders"].Map()) != 0 || len(doc.Paths["/fine"].Map()) != 1 {
+		t.Fatalf("expected only unsupported operations to be removed")
+	}
+}
+
+func TestYAML(t *testing.T) {
+	src := buildClient(t, yamlSpec, Options{}, yamlCall, nil)
+	for _, expected := range []string{
+		"Name string `json:\"name,omitempty\"`",
+		"Tag string `json:\"tag,omitempty\"`",
+		"Born string `json:\"born,omitempty\"`",
+	} {
+		if !strings.Contains(src, expected) {
+			t.Fatalf("expected %s in\n%s", expected, src)
+		}
+	}
+}
+
+func TestExternalReferences(t *testing.T) {
+	dir, err := ioutil.TempDir("", "openapi-client")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"openapi.yaml":              externalSpec,
+		"schemas/user.yaml":         "User:\n  type: object\n  properties:\n    friends:\n      type: array\n      items:\n        $ref: '#/User'\n    address:\n      $ref: ./address.yaml\n    profile:\n      $ref: ./user-profile.yaml\n",
+		"schemas/address.yaml":      "type: object\nproperties:\n  city:\n    type: string\n",
+		"params.yaml":               "Limit:\n  name: limit\n  in: query\n  schema:\n    type: integer\n",
+		"schemas/user-profile.yaml": "type: object\nproperties:\n  nick:\n    type: string\n",
+	}
+	for name, content := range files {
+		fname := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(fname), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(fname, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	out := &bytes.Buffer{}
+	if err := GenerateFile(filepath.Join(dir, "openapi.yaml"), Options{TargetPackage: "blub", Output: out}); err != nil {
+		t.Fatal(err)
+	}
+
+	src := out.String()
+	for _, expected := range []string{
+		"type Address2 struct",
+		"type UserProfile struct",
+		"Friends []User `json:\"friends,omitempty\"`",
+		"limit *int, offset *int",
+	} {
+		if !strings.Contains(src, expected) {
+			t.Fatalf("expected %s in\n%s", expected, src)
+		}
+	}
+}
+
+func TestSwagger(t *testing.T) {
+	src := buildClient(t, swaggerSpec, Options{}, "", nil)
+	if !strings.Contains(src, "encodeMultipart(body)") {
+		t.Fatalf("expected the form parameters to be sent as multipart in\n%s", src)
+	}
+}
+
+func TestParamNames(t *testing.T) {
+	src := buildClient(t, paramNamesSpec, Options{}, paramNamesCall, nil)
+	if !strings.Contains(src, "path2 string, url2 string, f2 int, query string, string2 string, body2 string, path3 string, addQueryParam2 string, body string") {
+		t.Fatalf("expected renamed parameters in\n%s", src)
+	}
+}
+
+func TestOptionalParams(t *testing.T) {
+	src := buildClient(t, optionalParamsSpec, Options{}, optionalParamsCall, nil)
+	if !strings.Contains(src, "flag *bool, count *int, name *string, tags []string, xTrace *int, id int") {
+		t.Fatalf("expected optional parameters as pointers in\n%s", src)
+	}
+}
+
+func TestRequestBodies(t *testing.T) {
+	buildClient(t, requestBodySpec, Options{}, requestBodyCall, nil)
+
+	xml := strings.Replace(requestBodySpec, `"application/octet-stream":{
+                     "schema":{
+                        "type":"string",
+                        "format":"byte"`, `"application/xml":{
+                     "schema":{
+                        "type":"object"`, 1)
+	err := Generate([]byte(xml), Options{TargetPackage: "blub", Output: &bytes.Buffer{}})
+	var diagnostics Diagnostics
+	if !errors.As(err, &diagnostics) || len(diagnostics) != 1 ||
+		diagnostics[0].Error() != "#/paths/~1raw/put/requestBody/content/application~1xml: unsupported media type 'application/xml' for a request body of type map[string]json.RawMessage" {
+		t.Fatalf("expected a diagnostic for the xml body but got %v", err)
+	}
+}
+
+func TestFormBodies(t *testing.T) {
+	buildClient(t, formSpec, Options{}, formCall, nil)
+}
+
+func TestHeaderAndCookieParams(t *testing.T) {
+	buildClient(t, headerParamsSpec, Options{}, headerParamsCall, nil)
+}
+
+func TestParamStyles(t *testing.T) {
+	buildClient(t, paramStylesSpec, Options{}, paramStylesCall, nil)
+}
+
+func TestQueryString(t *testing.T) {
+	buildClient(t, queryStringSpec, Options{}, queryStringCall, nil)
+}
+
+func TestResponses(t *testing.T) {
+	buildClient(t, responsesSpec, Options{}, responsesCall, nil)
+}
+
+func TestErrorResponses(t *testing.T) {
+	buildClient(t, errorResponsesSpec, Options{}, errorResponsesCall, nil)
+}
+
+func TestUndeclaredErrors(t *testing.T) {
+	buildClient(t, spec, Options{}, golangeeErrorCall, nil)
+	buildClient(t, spec, Options{ErrorModel: ProblemErrorModel}, problemErrorCall, nil)
+}
+
+// buildClient generates a client from the spec into a temporary module and verifies it with go vet. The given
+// test source is placed next to the client and run with go test. Stubs maps the import paths of referenced
+// packages to their source, so that mapped types resolve without any network access.
+func buildClient(t *testing.T, spec string, opts Options, tests string, stubs map[string]string) string {
+	t.Helper()
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go tool not available")
+	}
+
+	buf := &bytes.Buffer{}
+	opts.Output = buf
+	opts.TargetPackage = "blub"
+	if err := Generate([]byte(spec), opts); err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := ioutil.TempDir("", "openapi-client")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	mod := "module blub\n\ngo 1.14\n"
+	files := map[string]string{"openapiclient.gen.go": buf.String()}
+	if tests != "" {
+		files["client_test.go"] = tests
+	}
+
+	for i, importPath := range gen.SortedKeys(stubs) {
+		stubDir := fmt.Sprintf("stubs/%d", i)
+		mod += fmt.Sprintf("\nrequire %s v0.0.0\n\nreplace %s => ./%s\n", importPath, importPath, stubDir)
+		files[stubDir+"/go.mod"] = "module " + importPath + "\n\ngo 1.14\n"
+		files[stubDir+"/stub.go"] = stubs[importPath]
+	}
+	files["go.mod"] = mod
+
+	for name, content := range files {
+		fname := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(fname), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(fname, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	commands := [][]string{{"vet", "./..."}}
+	if tests != "" {
+		commands = append(commands, []string{"test", "./..."})
+	}
+
+	for _, args := range commands {
+		cmd := exec.Command("go", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GOFLAGS=-mod=mod", "GOPROXY=off", "GOWORK=off")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("go %s failed: %v\n%s\n%s", strings.Join(args, " "), err, out, buf.String())
+		}
+	}
+
+	return buf.String()
+}
+
+const propertyNamesRoundTrip = `package blub
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestRoundTrip(t *testing.T) {
+	buf, err := json.Marshal(Event{CreatedAt: "today"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(buf) != ` + "`" + `{"createdAt":"today"}` + "`" + ` {
+		t.Fatalf("expected only the required property but got %s", buf)
+	}
+
+	var e Event
+	src := ` + "`" + `{"Type":"b","createdAt":"today","type":"a","user_id":"jo","x-count":3}` + "`" + `
+	if err := json.Unmarshal([]byte(src), &e); err != nil {
+		t.Fatal(err)
+	}
+	if e.UserId != "jo" || e.XCount != 3 || e.Type != "b" || e.Type2 != "a" {
+		t.Fatalf("unexpected event %+v", e)
+	}
+
+	buf, err = json.Marshal(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(buf) != src {
+		t.Fatalf("expected %s but got %s", src, buf)
+	}
+}
+`
+
+const propertyNamesSpec = `{
+   "openapi":"3.0.1",
+   "info":{
+      "title":"test",
+      "version":""
+   },
+   "paths":{
+      "/events":{
+         "get":{
+            "tags":[
+               "events"
+            ],
+            "responses":{
+               "200":{
+                  "description":"the latest event",
+                  "content":{
+                     "application/json":{
+                        "schema":{
+                           "$ref":"#/components/schemas/Event"
+                        }
+                     }
+                  }
+               }
+            }
+         }
+      }
+   },
+   "components":{
+      "schemas":{
+         "Event":{
+            "type":"object",
+            "required":[
+               "createdAt"
+            ],
+            "properties":{
+               "createdAt":{
+                  "type":"string"
+               },
+               "user_id":{
+                  "type":"string"
+               },
+               "x-count":{
+                  "type":"integer"
+               },
+               "type":{
+                  "type":"string"
+               },
+               "Type":{
+                  "type":"string"
+               }
+            }
+         }
+      }
+   }
+}
+`
+
+const headerParamsCall = `package blub
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestCall(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var cookies []string
+		for _, name := range []string{"session", "ids"} {
+			cookie, err := r.Cookie(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			cookies = append(cookies, cookie.Value)
+		}
+
+		actual := append([]string{r.Header.Get("X-Tenant-ID"), r.Header.Get("If-Match")}, cookies...)
+		expected := []string{"t1", "a,b", "s1", "1,2"}
+		for i := range expected {
+			if actual[i] != expected[i] {
+				t.Errorf("expected %s but got %s", expected[i], actual[i])
+			}
+		}
+		_ = json.NewEncoder(w).Encode("ok")
+	}))
+	defer srv.Close()
+
+	u, _ := url.Parse(srv.URL)
+	res, err := NewTestService(u, "", nil).TenantsService().syncTenants(context.Background(), "t1", []string{"a", "b"}, "s1", []int{1, 2})
+	if err != nil || res != "ok" {
+		t.Fatalf("unexpected %s: %v", res, err)
+	}
+}
+`
+
+const headerParamsSpec = `{
+   "openapi":"3.0.1",
+   "info":{
+      "title":"test",
+      "version":""
+   },
+   "paths":{
+      "/tenants":{
+         "get":{
+            "tags":[
+               "tenants"
+            ],
+            "parameters":[
+               {"name":"X-Tenant-ID","in":"header","required":true,"schema":{"type":"string"}},
+               {"name":"If-Match","in":"header","required":true,"schema":{"type":"array","items":{"type":"string"}}},
+               {"name":"session","in":"cookie","required":true,"schema":{"type":"string"}},
+               {"name":"ids","in":"cookie","required":true,"explode":false,"schema":{"type":"array","items":{"type":"integer"}}}
+            ],
+            "responses":{
+               "200":{
+                  "description":"",
+                  "content":{
+                     "application/json":{
+                        "schema":{"type":"string"}
+                     }
+                  }
+               }
+            }
+         }
+      }
+   }
+}
+`
+
+const paramStylesCall = `package blub
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestCall(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		expectedPath := "/files/a%2Fb%20c/.x,y/;role=admin;size=2/;tags=x;tags=y"
+		if r.URL.EscapedPath() != expectedPath {
+			t.Errorf("expected path %s but got %s", expectedPath, r.URL.EscapedPath())
+		}
+
+		expectedQuery := url.Values{
+			"ids":          {"1,2"},
+			"names":        {"a b"},
+			"codes":        {"x|y"},
+			"filter[role]": {"admin"},
+			"filter[size]": {"2"},
+			"limit":        {"10"},
+			"offset":       {"9007199254740993"},
+			"sort":         {"name", "-date"},
+		}
+		if !reflect.DeepEqual(r.URL.Query(), expectedQuery) {
+			t.Errorf("expected query %v but got %v", expectedQuery, r.URL.Query())
+		}
+
+		if r.Header.Get("X-Filter") != "role=admin,size=2" {
+			t.Errorf("unexpected header %s", r.Header.Get("X-Filter"))
+		}
+		_ = json.NewEncoder(w).Encode("ok")
+	}))
+	defer srv.Close()
+
+	u, _ := url.Parse(srv.URL)
+	svc := NewTestService(u, "", nil).FilesService()
+	filter := Filter{Role: "admin", Size: 2}
+	res, err := svc.syncFilesIdLabelMatrixTags(context.Background(), "a/b c", []string{"x", "y"}, filter, []string{"x", "y"},
+		[]int{1, 2}, []string{"a", "b"}, []string{"x", "y"}, filter, Page{Limit: 10, Offset: 9007199254740993}, []string{"name", "-date"}, filter)
+	if err != nil || res != "ok" {
+		t.Fatalf("unexpected %s: %v", res, err)
+	}
+}
+`
+
+const paramStylesSpec = `{
+   "openapi":"3.0.1",
+   "info":{
+      "title":"test",
+      "version":""
+   },
+   "paths":{
+      "/files/{id}/{label}/{matrix}/{tags}":{
+         "get":{
+            "tags":[
+               "files"
+            ],
+            "parameters":[
+               {"name":"id","in":"path","required":true,"schema":{"type":"string"}},
+               {"name":"label","in":"path","required":true,"style":"label","schema":{"type":"array","items":{"type":"string"}}},
+               {"name":"matrix","in":"path","required":true,"style":"matrix","explode":true,"schema":{"$ref":"#/components/schemas/Filter"}},
+               {"name":"tags","in":"path","required":true,"style":"matrix","explode":true,"schema":{"type":"array","items":{"type":"string"}}},
+               {"name":"ids","in":"query","required":true,"explode":false,"schema":{"type":"array","items":{"type":"integer"}}},
+               {"name":"names","in":"query","required":true,"style":"spaceDelimited","explode":false,"schema":{"type":"array","items":{"type":"string"}}},
+               {"name":"codes","in":"query","required":true,"style":"pipeDelimited","explode":false,"schema":{"type":"array","items":{"type":"string"}}},
+               {"name":"filter","in":"query","required":true,"style":"deepObject","explode":true,"schema":{"$ref":"#/components/schemas/Filter"}},
+               {"name":"page","in":"query","required":true,"schema":{"$ref":"#/components/schemas/Page"}},
+               {"name":"sort","in":"query","required":true,"schema":{"type":"array","items":{"type":"string"}}},
+               {"name":"X-Filter","in":"header","required":true,"explode":true,"schema":{"$ref":"#/components/schemas/Filter"}}
+            ],
+            "responses":{
+               "200":{
+                  "description":"",
+                  "content":{
+                     "application/json":{
+                        "schema":{"type":"string"}
+                     }
+                  }
+               }
+            }
+         }
+      }
+   },
+   "components":{
+      "schemas":{
+         "Filter":{
+            "type":"object",
+            "properties":{
+               "role":{
+                  "type":"string"
+               },
+               "size":{
+                  "type":"integer"
+               }
+            }
+         },
+         "Page":{
+            "type":"object",
+            "properties":{
+               "limit":{
+                  "type":"integer"
+               },
+               "offset":{
+                  "type":"integer"
+               }
+            }
+         }
+      }
+   }
+}
+`
+
+const queryStringCall = `package blub
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestCall(t *testing.T) {
+	var uris, traces []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		uris = append(uris, r.RequestURI)
+		traces = append(traces, r.Header.Get("X-Trace"))
+		_ = json.NewEncoder(w).Encode("ok")
+	}))
+	defer srv.Close()
+
+	u, _ := url.Parse(srv.URL)
+	svc := NewTestService(u, "", nil).SearchService()
+	if _, err := svc.syncPing(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := svc.syncSearch(context.Background(), nil, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	q, limit, trace := "a&b=c d?", 10, "t1"
+	if _, err := svc.syncSearch(context.Background(), &q, &limit, &trace); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"/ping", "/search", "/search?limit=10&q=a%26b%3Dc+d%3F"}
+	for i := range expected {
+		if uris[i] != expected[i] {
+			t.Errorf("expected %s but got %s", expected[i], uris[i])
+		}
+	}
+
+	if traces[1] != "" || traces[2] != "t1" {
+		t.Errorf("expected the unset header to be omitted but got %v", traces)
+	}
+}
+`
+
+const queryStringSpec = `{
+   "openapi":"3.0.1",
+   "info":{
+      "title":"test",
+      "version":""
+   },
+   "paths":{
+      "/ping":{
+         "get":{
+            "tags":[
+               "search"
+            ],
+            "responses":{
+               "200":{
+                  "description":"",
+                  "content":{
+                     "application/json":{
+                        "schema":{"type":"string"}
+                     }
+                  }
+               }
+            }
+         }
+      },
+      "/search":{
+         "get":{
+            "tags":[
+               "search"
+            ],
+            "parameters":[
+               {"name":"q","in":"query","schema":{"type":"string"}},
+               {"name":"limit","in":"query","schema":{"type":"integer"}},
+               {"name":"X-Trace","in":"header","schema":{"type":"string"}}
+            ],
+            "responses":{
+               "200":{
+                  "description":"",
+                  "content":{
+                     "application/json":{
+                        "schema":{"type":"string"}
+                     }
+                  }
+               }
+            }
+         }
+      }
+   }
+}
+`
+
+const responsesCall = `package blub
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestCall(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "PUT /items":
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(` + "`" + `{"name":"a"}` + "`" + `))
+		case "DELETE /items":
+			w.WriteHeader(http.StatusNoContent)
+		case "GET /empty":
+			w.WriteHeader(http.StatusAccepted)
+		case "GET /text":
+			if r.Header.Get("Accept") != "text/plain" {
+				t.Errorf("unexpected accept header %s", r.Header.Get("Accept"))
+			}
+			_, _ = w.Write([]byte("hello"))
+		case "GET /blob":
+			_, _ = w.Write([]byte{0, 1, 2})
+		case "GET /range":
+			w.WriteHeader(http.StatusPartialContent)
+			_, _ = w.Write([]byte("206"))
+		case "GET /fallback":
+			_, _ = w.Write([]byte("200"))
+		}
+	}))
+	defer srv.Close()
+
+	u, _ := url.Parse(srv.URL)
+	svc := NewTestService(u, "", nil).ItemsService()
+	ctx := context.Background()
+	if item, err := svc.syncPutItems(ctx); err != nil || item.Name != "a" {
+		t.Errorf("unexpected created item %v: %v", item, err)
+	}
+
+	if err := svc.syncDeleteItems(ctx); err != nil {
+		t.Errorf("unexpected error for no content: %v", err)
+	}
+
+	if item, err := svc.syncEmpty(ctx); err != nil || item.Name != "" {
+		t.Errorf("unexpected accepted item %v: %v", item, err)
+	}
+
+	if text, err := svc.syncText(ctx); err != nil || text != "hello" {
+		t.Errorf("unexpected text %s: %v", text, err)
+	}
+
+	if blob, err := svc.syncBlob(ctx); err != nil || string(blob) != "\x00\x01\x02" {
+		t.Errorf("unexpected blob %v: %v", blob, err)
+	}
+
+	if n, err := svc.syncRange(ctx); err != nil || n != 206 {
+		t.Errorf("unexpected range result %d: %v", n, err)
+	}
+
+	if n, err := svc.syncFallback(ctx); err != nil || n != 200 {
+		t.Errorf("unexpected default result %d: %v", n, err)
+	}
+}
+`
+
+const responsesSpec = `{
+   "openapi":"3.0.1",
+   "info":{
+      "title":"test",
+      "version":""
+   },
+   "paths":{
+      "/items":{
+         "put":{
+            "tags":[
+               "items"
+            ],
+            "responses":{
+               "201":{
+                  "description":"",
+                  "content":{
+                     "application/json":{
+                        "schema":{"$ref":"#/components/schemas/Item"}
+                     }
+                  }
+               }
+            }
+         },
+         "delete":{
+            "tags":[
+               "items"
+            ],
+            "responses":{
+               "204":{
+                  "description":""
+               }
+            }
+         }
+      },
+      "/empty":{
+         "get":{
+            "tags":[
+               "items"
+            ],
+            "responses":{
+               "202":{
+                  "description":"",
+                  "content":{
+                     "application/json":{
+                        "schema":{"$ref":"#/components/schemas/Item"}
+                     }
+                  }
+               }
+            }
+         }
+      },
+      "/text":{
+         "get":{
+            "tags":[
+               "items"
+            ],
+            "responses":{
+               "200":{
+                  "description":"",
+                  "content":{
+                     "text/plain":{
+                        "schema":{"type":"string"}
+                     }
+                  }
+               }
+            }
+         }
+      },
+      "/blob":{
+         "get":{
+            "tags":[
+               "items"
+            ],
+            "responses":{
+               "200":{
+                  "description":"",
+                  "content":{
+                     "application/octet-stream":{
+                        "schema":{"type":"string","format":"binary"}
+                     }
+                  }
+               }
+            }
+         }
+      },
+      "/range":{
+         "get":{
+            "tags":[
+               "items"
+            ],
+            "responses":{
+               "2XX":{
+                  "description":"",
+                  "content":{
+                     "application/json":{
+                        "schema":{"type":"integer"}
+                     }
+                  }
+               }
+            }
+         }
+      },
+      "/fallback":{
+         "get":{
+            "tags":[
+               "items"
+            ],
+            "responses":{
+               "default":{
+                  "description":"",
+                  "content":{
+                     "application/json":{
+                        "schema":{"type":"integer"}
+                     }
+                  }
+               }
+            }
+         }
+      }
+   },
+   "components":{
+      "schemas":{
+         "Item":{
+            "type":"object",
+            "properties":{
+               "name":{
+                  "type":"string"
+               }
+            }
+         }
+      }
+   }
+}
+`
+
+const errorResponsesCall = `package blub
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+)
+
+func TestCall(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status, _ := strconv.Atoi(r.URL.Path[len("/users/"):])
+		w.WriteHeader(status)
+		switch status {
+		case http.StatusNotFound:
+			_, _ = w.Write([]byte(` + "`" + `{"message":"no such user"}` + "`" + `))
+		case http.StatusUnprocessableEntity:
+			_, _ = w.Write([]byte(` + "`" + `{"field":"name"}` + "`" + `))
+		case http.StatusInternalServerError:
+			_, _ = w.Write([]byte(` + "`" + `{"message":"boom"}` + "`" + `))
+		case http.StatusBadGateway:
+			_, _ = w.Write([]byte("<html>bad gateway</html>"))
+		}
+	}))
+	defer srv.Close()
+
+	u, _ := url.Parse(srv.URL)
+	svc := NewTestService(u, "", nil).UsersService()
+	ctx := context.Background()
+
+	_, err := svc.syncUsersId(ctx, "404")
+	var notFound *UsersIdNotFoundError
+	if !errors.As(err, &notFound) || notFound.StatusCode != 404 || notFound.Body.Message != "no such user" {
+		t.Errorf("expected the not found error but got %v", err)
+	}
+
+	_, err = svc.syncUsersId(ctx, "422")
+	var clientErr *UsersIdClientError
+	if !errors.As(err, &clientErr) || clientErr.StatusCode != 422 || clientErr.Body.Field != "name" {
+		t.Errorf("expected the client error but got %v", err)
+	}
+
+	_, err = svc.syncUsersId(ctx, "500")
+	var serverErr *UsersIdServerError
+	if !errors.As(err, &serverErr) || serverErr.Body.Message != "boom" || serverErr.Error() != "500 Internal Server Error" {
+		t.Errorf("expected the server error but got %v", err)
+	}
+
+	_, err = svc.syncUsersId(ctx, "502")
+	if err == nil || errors.As(err, &serverErr) {
+		t.Errorf("expected an undeclared error for undecodable content but got %v", err)
+	}
+}
+`
+
+const errorResponsesSpec = `{
+   "openapi":"3.0.1",
+   "info":{
+      "title":"test",
+      "version":""
+   },
+   "paths":{
+      "/users/{id}":{
+         "get":{
+            "tags":[
+               "users"
+            ],
+            "parameters":[
+               {"name":"id","in":"path","required":true,"schema":{"type":"string"}}
+            ],
+            "responses":{
+               "200":{
+                  "description":"",
+                  "content":{
+                     "application/json":{
+                        "schema":{"type":"string"}
+                     }
+                  }
+               },
+               "404":{
+                  "description":"",
+                  "content":{
+                     "application/json":{
+                        "schema":{"$ref":"#/components/schemas/Message"}
+                     }
+                  }
+               },
+               "4XX":{
+                  "description":"",
+                  "content":{
+                     "application/problem+json":{
+                        "schema":{"$ref":"#/components/schemas/ValidationProblem"}
+                     }
+                  }
+               },
+               "5XX":{
+                  "description":"",
+                  "content":{
+                     "application/json":{
+                        "schema":{"$ref":"#/components/schemas/Message"}
+                     }
+                  }
+               }
+            }
+         }
+      }
+   },
+   "components":{
+      "schemas":{
+         "Message":{
+            "type":"object",
+            "properties":{
+               "message":{
+                  "type":"string"
+               }
+            }
+         },
+         "ValidationProblem":{
+            "type":"object",
+            "properties":{
+               "field":{
+                  "type":"string"
+               }
+            }
+         }
+      }
+   }
+}
+`
+
+const golangeeErrorCall = `package blub
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestCall(t *testing.T) {
+	var status int
+	var body string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-Id", "r1")
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}))
+	defer srv.Close()
+
+	u, _ := url.Parse(srv.URL)
+	svc := NewTestService(u, "", nil).SetupService()
+
+	status, body = http.StatusBadGateway, "<html>\n<body>proxy failure</body>\n</html>"
+	_, err := svc.syncApiV1SetupStatus(context.Background())
+	var resErr *ResponseError
+	if !errors.As(err, &resErr) || resErr.StatusCode != 502 || resErr.Header.Get("X-Request-Id") != "r1" || string(resErr.RawBody) != body {
+		t.Fatalf("expected the response error but got %v", err)
+	}
+	if err.Error() != "502 Bad Gateway: <html> <body>proxy failure</body> </html>" {
+		t.Errorf("unexpected message %s", err.Error())
+	}
+
+	status, body = http.StatusBadRequest, ` + "`" + `{"id":"invalid.status","message":"status is invalid"}` + "`" + `
+	_, err = svc.syncApiV1SetupStatus(context.Background())
+	var modelErr *Error
+	if !errors.As(err, &modelErr) || modelErr.Id != "invalid.status" || err.Error() != "400 Bad Request: status is invalid" {
+		t.Errorf("expected the error model but got %v", err)
+	}
+
+	status, body = http.StatusInternalServerError, strings.Repeat("x", maxErrorBodySize+1)
+	_, err = svc.syncApiV1SetupStatus(context.Background())
+	if !errors.As(err, &resErr) || len(resErr.RawBody) != maxErrorBodySize {
+		t.Errorf("expected a bounded body but got %d bytes", len(resErr.RawBody))
+	}
+}
+`
+
+const problemErrorCall = `package blub
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestCall(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/problem+json")
+		w.WriteHeader(http.StatusConflict)
+		_, _ = w.Write([]byte(` + "`" + `{"title":"Conflict","detail":"already applied","status":409,"step":3}` + "`" + `))
+	}))
+	defer srv.Close()
+
+	u, _ := url.Parse(srv.URL)
+	_, err := NewTestService(u, "", nil).SetupService().syncApiV1SetupStatus(context.Background())
+	var problem *Problem
+	if !errors.As(err, &problem) || problem.Status != 409 || string(problem.Extensions["step"]) != "3" {
+		t.Fatalf("expected the problem but got %v", err)
+	}
+	if err.Error() != "409 Conflict: Conflict: already applied" {
+		t.Errorf("unexpected message %s", err.Error())
+	}
+}
+`
+
+const formCall = `package blub
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestCall(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var expected url.Values
+		switch r.URL.Path {
+		case "/login":
+			if err := r.ParseForm(); err != nil {
+				t.Fatal(err)
+			}
+			expected = url.Values{"user": {"jo"}, "remember": {"false"}, "scopes": {"a", "b"}}
+		case "/upload":
+			if err := r.ParseMultipartForm(1024); err != nil {
+				t.Fatal(err)
+			}
+			expected = url.Values{"name": {"x.txt"}, "count": {"0"}}
+
+			file, _, err := r.FormFile("file")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if buf, _ := ioutil.ReadAll(file); string(buf) != "content" {
+				t.Errorf("unexpected file content %s", buf)
+			}
+		}
+
+		if !reflect.DeepEqual(r.PostForm, expected) {
+			t.Errorf("expected %v but got %v", expected, r.PostForm)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	u, _ := url.Parse(srv.URL)
+	svc := NewTestService(u, "", nil).DefaultService()
+	if err := svc.syncPostLogin(context.Background(), PostLoginRequest{User: "jo", Scopes: []string{"a", "b"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := svc.syncPostUpload(context.Background(), PostUploadRequest{Name: "x.txt", File: []byte("content")}); err != nil {
+		t.Fatal(err)
+	}
+}
+`
+
+const formSpec = `{
+   "openapi":"3.0.1",
+   "info":{
+      "title":"test",
+      "version":""
+   },
+   "paths":{
+      "/login":{
+         "post":{
+            "requestBody":{
+               "content":{
+                  "application/x-www-form-urlencoded":{
+                     "schema":{
+                        "type":"object",
+                        "required":["user", "remember", "scopes"],
+                        "properties":{
+                           "user":{
+                              "type":"string"
+                           },
+                           "remember":{
+                              "type":"boolean"
+                           },
+                           "scopes":{
+                              "type":"array",
+                              "items":{
+                                 "type":"string"
+                              }
+                           }
+                        }
+                     }
+                  }
+               }
+            },
+            "responses":{
+               "204":{
+                  "description":""
+               }
+            }
+         }
+      },
+      "/upload":{
+         "post":{
+            "requestBody":{
+               "content":{
+                  "multipart/form-data":{
+                     "schema":{
+                        "type":"object",
+                        "required":["name", "count", "file"],
+                        "properties":{
+                           "name":{
+                              "type":"string"
+                           },
+                           "count":{
+                              "type":"integer"
+                           },
+                           "file":{
+                              "type":"string",
+                              "format":"binary"
+                           }
+                        }
+                     }
+                  }
+               }
+            },
+            "responses":{
+               "204":{
+                  "description":""
+               }
+            }
+         }
+      }
+   }
+}
+`
+
+const requestBodyCall = `package blub
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestCall(t *testing.T) {
+	var bodies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		buf, _ := ioutil.ReadAll(r.Body)
+		bodies = append(bodies, r.Header.Get("Content-Type")+" "+string(buf))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	u, _ := url.Parse(srv.URL)
+	svc := NewTestService(u, "", nil).DefaultService()
+	if err := svc.syncPostText(context.Background(), "hello"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := svc.syncPutRaw(context.Background(), []byte{1, 2}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := svc.syncPutStream(context.Background(), strings.NewReader("stream")); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"text/plain hello", "application/octet-stream \x01\x02", "application/octet-stream stream"}
+	for i := range expected {
+		if bodies[i] != expected[i] {
+			t.Fatalf("expected %q but got %q", expected[i], bodies[i])
+		}
+	}
+}
+`
+
+const requestBodySpec = `{
+   "openapi":"3.0.1",
+   "info":{
+      "title":"test",
+      "version":""
+   },
+   "paths":{
+      "/text":{
+         "post":{
+            "requestBody":{
+               "content":{
+                  "text/plain":{
+                     "schema":{
+                        "type":"string"
+                     }
+                  }
+               }
+            },
+            "responses":{
+               "204":{
+                  "description":""
+               }
+            }
+         }
+      },
+      "/raw":{
+         "put":{
+            "requestBody":{
+               "content":{
+                  "application/octet-stream":{
+                     "schema":{
+                        "type":"string",
+                        "format":"byte"
+                     }
+                  }
+               }
+            },
+            "responses":{
+               "204":{
+                  "description":""
+               }
+            }
+         }
+      },
+      "/stream":{
+         "put":{
+            "requestBody":{
+               "content":{
+                  "application/octet-stream":{
+                     "schema":{
+                        "type":"string",
+                        "format":"binary"
+                     }
+                  }
+               }
+            },
+            "responses":{
+               "204":{
+                  "description":""
+               }
+            }
+         }
+      }
+   }
+}
+`
+
+const optionalParamsCall = `package blub
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestCall(t *testing.T) {
+	var query, trace []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = append(query, r.URL.RawQuery)
+		trace = append(trace, r.Header.Get("X-Trace"))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	u, _ := url.Parse(srv.URL)
+	svc := NewTestService(u, "", nil).DefaultService()
+	flag, count, name := false, 0, ""
+	if err := svc.syncSearch(context.Background(), &flag, &count, &name, []string{}, &count, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := svc.syncSearch(context.Background(), nil, nil, nil, nil, nil, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	if query[0] != "count=0&flag=false&id=0&name=" || trace[0] != "0" {
+		t.Fatalf("expected zero values to be sent but got %s and %s", query[0], trace[0])
+	}
+
+	if query[1] != "id=0" || trace[1] != "" {
+		t.Fatalf("expected nil values to be omitted but got %s and %s", query[1], trace[1])
+	}
+}
+`
+
+const optionalParamsSpec = `{
+   "openapi":"3.0.1",
+   "info":{
+      "title":"test",
+      "version":""
+   },
+   "paths":{
+      "/search":{
+         "get":{
+            "parameters":[
+               {"name":"flag","in":"query","schema":{"type":"boolean"}},
+               {"name":"count","in":"query","schema":{"type":"integer"}},
+               {"name":"name","in":"query","schema":{"type":"string"}},
+               {"name":"tags","in":"query","schema":{"type":"array","items":{"type":"string"}}},
+               {"name":"X-Trace","in":"header","schema":{"type":"integer"}},
+               {"name":"id","in":"query","required":true,"schema":{"type":"integer"}}
+            ],
+            "responses":{
+               "204":{
+                  "description":""
+               }
+            }
+         }
+      }
+   }
+}
+`
+
+const paramNamesCall = `package blub
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestCall(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body string
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Error(err)
+		}
+
+		cookie, err := r.Cookie("addQueryParam")
+		if err != nil {
+			t.Error(err)
+		}
+
+		q := r.URL.Query()
+		actual := []string{r.URL.Path, q.Get("url"), q.Get("f"), q.Get("_query"), q.Get("string"), r.Header.Get("body"),
+			r.Header.Get("Path"), cookie.Value, body}
+		expected := []string{"/items/a", "b", "1", "c", "d", "e", "g", "h", "i"}
+		for i := range expected {
+			if actual[i] != expected[i] {
+				t.Errorf("expected %s but got %s", expected[i], actual[i])
+			}
+		}
+		_ = json.NewEncoder(w).Encode("ok")
+	}))
+	defer srv.Close()
+
+	u, _ := url.Parse(srv.URL)
+	done := make(chan struct{})
+	NewTestService(u, "", nil).DefaultService().PutItemsPath(context.Background(), "a", "b", 1, "c", "d", "e", "g", "h", "i", func(res string, err error) {
+		defer close(done)
+		if err != nil || res != "ok" {
+			t.Errorf("unexpected %s: %v", res, err)
+		}
+	})
+	<-done
+}
+`
+
+const paramNamesSpec = `{
+   "openapi":"3.0.1",
+   "info":{
+      "title":"test",
+      "version":""
+   },
+   "paths":{
+      "/items/{path}":{
+         "put":{
+            "operationId":"putItem",
+            "parameters":[
+               {"name":"path","in":"path","required":true,"schema":{"type":"string"}},
+               {"name":"url","in":"query","required":true,"schema":{"type":"string"}},
+               {"name":"f","in":"query","required":true,"schema":{"type":"integer"}},
+               {"name":"_query","in":"query","required":true,"schema":{"type":"string"}},
+               {"name":"string","in":"query","required":true,"schema":{"type":"string"}},
+               {"name":"body","in":"header","required":true,"schema":{"type":"string"}},
+               {"name":"Path","in":"header","required":true,"schema":{"type":"string"}},
+               {"name":"addQueryParam","in":"cookie","required":true,"schema":{"type":"string"}}
+            ],
+            "requestBody":{
+               "content":{
+                  "application/json":{
+                     "schema":{"type":"string"}
+                  }
+               }
+            },
+            "responses":{
+               "200":{
+                  "description":"",
+                  "content":{
+                     "application/json":{
+                        "schema":{"type":"string"}
+                     }
+                  }
+               }
+            }
+         }
+      }
+   }
+}
+`
+
+const domainStub = `package domain
+
+import (
+	"encoding/json"
+	"image/color"
+)
+
+type Money struct {
+	Amount string
+}
+
+type Address struct {
+	Street string
+}
+
+func MarshalColor(c color.RGBA) ([]byte, error) {
+	return json.Marshal([]uint8{c.R, c.G, c.B, c.A})
+}
+
+func MarshalMoney(m Money) ([]byte, error) {
+	return json.Marshal(m.Amount)
+}
+
+func UnmarshalMoney(buf []byte) (Money, error) {
+	var m Money
+	err := json.Unmarshal(buf, &m.Amount)
+	return m, err
+}
+
+func UnmarshalColor(buf []byte) (color.RGBA, error) {
+	var v []uint8
+	err := json.Unmarshal(buf, &v)
+	return color.RGBA{R: v[0], G: v[1], B: v[2], A: v[3]}, err
+}
+`
+
+const strictEnumRoundTrip = `package blub
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestRoundTrip(t *testing.T) {
+	var task Task
+	if err := json.Unmarshal([]byte("{\"priority\":-1}"), &task); err != nil || task.Priority != TaskPriorityMinus1 {
+		t.Fatalf("unexpected %v: %v", task, err)
+	}
+
+	buf, err := json.Marshal(task)
+	if err != nil || string(buf) != "{\"priority\":-1}" {
+		t.Fatalf("unexpected %s: %v", buf, err)
+	}
+
+	var state State
+	if err := json.Unmarshal([]byte("\"in-progress\""), &state); err != nil || state != StateInProgress {
+		t.Fatalf("unexpected %v: %v", state, err)
+	}
+
+	if err := json.Unmarshal([]byte("\"unknown\""), &state); err == nil {
+		t.Fatal("expected an error for an undeclared value")
+	}
+
+	if err := json.Unmarshal([]byte("{\"priority\":2}"), &task); err == nil {
+		t.Fatal("expected an error for an undeclared value")
+	}
+
+	task = Task{Color: ColorRed}
+	if err := json.Unmarshal([]byte("{\"color\":null}"), &task); err != nil || task.Color != ColorRed {
+		t.Fatalf("expected null to keep the value but got %v: %v", task, err)
+	}
+
+	if err := json.Unmarshal([]byte("{\"color\":\"red\"}"), &task); err != nil || task.Color != ColorRed {
+		t.Fatalf("unexpected %v: %v", task, err)
+	}
+}
+`
+
+const lenientEnumRoundTrip = `package blub
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestRoundTrip(t *testing.T) {
+	var state State
+	if err := json.Unmarshal([]byte("\"unknown\""), &state); err != nil || state.Valid() {
+		t.Fatalf("expected an invalid but accepted value but got %v: %v", state, err)
+	}
+
+	buf, err := json.Marshal(state)
+	if err != nil || string(buf) != "\"unknown\"" {
+		t.Fatalf("unexpected %s: %v", buf, err)
+	}
+
+	var task Task
+	if err := json.Unmarshal([]byte("{\"priority\":2}"), &task); err != nil || task.Priority.Valid() {
+		t.Fatalf("expected an invalid but accepted value but got %v: %v", task, err)
+	}
+}
+`
+
+const unionRoundTrip = `package blub
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestRoundTrip(t *testing.T) {
+	var pet Pet
+	if err := json.Unmarshal([]byte("{\"kind\":\"kitty\"}"), &pet); err != nil {
+		t.Fatal(err)
+	}
+
+	if cat, ok := pet.AsCat(); !ok || cat.Kind != "kitty" {
+		t.Fatalf("expected a cat but got %v", pet.Value())
+	}
+
+	buf, err := json.Marshal(NewPetFromDog(Dog{Kind: "Dog"}))
+	if err != nil || string(buf) != "{\"kind\":\"Dog\"}" {
+		t.Fatalf("unexpected %s: %v", buf, err)
+	}
+
+	if err := json.Unmarshal(buf, &pet); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := pet.AsDog(); !ok {
+		t.Fatalf("expected a dog but got %v", pet.Value())
+	}
+
+	if err := json.Unmarshal([]byte("{\"kind\":\"bird\"}"), &pet); err == nil {
+		t.Fatal("expected an error for an unknown discriminator value")
+	}
+
+	var owner Owner
+	if err := json.Unmarshal([]byte("{\"contact\":42}"), &owner); err != nil {
+		t.Fatal(err)
+	}
+
+	if v, ok := owner.Contact.AsInt(); !ok || v != 42 {
+		t.Fatalf("expected an int but got %v", owner.Contact.Value())
+	}
+
+	if err := json.Unmarshal([]byte("{\"contact\":\"mail\"}"), &owner); err != nil {
+		t.Fatal(err)
+	}
+
+	if v, ok := owner.Contact.AsString(); !ok || v != "mail" {
+		t.Fatalf("expected a string but got %v", owner.Contact.Value())
+	}
+
+	buf, err = json.Marshal(owner)
+	if err != nil || string(buf) != "{\"contact\":\"mail\"}" {
+		t.Fatalf("unexpected %s: %v", buf, err)
+	}
+
+	if err := json.Unmarshal([]byte("{\"contact\":true}"), &owner); err == nil {
+		t.Fatal("expected an error for a value without matching variant")
+	}
+}
+`
+
+const mapRoundTrip = `package blub
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestRoundTrip(t *testing.T) {
+	var tagged Tagged
+	if err := json.Unmarshal([]byte("{\"name\":\"a\",\"x\":1,\"y\":2}"), &tagged); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := Tagged{Name: "a", AdditionalProperties: map[string]int{"x": 1, "y": 2}}
+	if !reflect.DeepEqual(tagged, expected) {
+		t.Fatalf("expected %v but got %v", expected, tagged)
+	}
+
+	buf, err := json.Marshal(tagged)
+	if err != nil || string(buf) != "{\"name\":\"a\",\"x\":1,\"y\":2}" {
+		t.Fatalf("unexpected %s: %v", buf, err)
+	}
+
+	if err := json.Unmarshal([]byte("{\"x\":\"text\"}"), &tagged); err == nil {
+		t.Fatal("expected an error for an additional property of the wrong type")
+	}
+
+	labels := Labels{"k": "v"}
+	buf, err = json.Marshal(labels)
+	if err != nil || string(buf) != "{\"k\":\"v\"}" {
+		t.Fatalf("unexpected %s: %v", buf, err)
+	}
+}
+`
+
+const dateRoundTrip = `package blub
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestRoundTrip(t *testing.T) {
+	buf, err := json.Marshal(Date{Year: 2020, Month: time.February, Day: 29})
+	if err != nil || string(buf) != "\"2020-02-29\"" {
+		t.Fatalf("unexpected %s: %v", buf, err)
+	}
+
+	var date Date
+	if err := json.Unmarshal(buf, &date); err != nil || date != (Date{Year: 2020, Month: time.February, Day: 29}) {
+		t.Fatalf("unexpected %v: %v", date, err)
+	}
+
+	if err := json.Unmarshal([]byte("\"2020-13-01\""), &date); err == nil {
+		t.Fatal("expected an error for an invalid date")
+	}
+}
+`
+
+const swaggerSpec = `{
+   "swagger":"2.0",
+   "info":{
+      "title":"test",
+      "version":""
+   },
+   "produces":[
+      "application/json"
+   ],
+   "parameters":{
+      "limit":{
+         "name":"limit",
+         "in":"query",
+         "type":"integer"
+      }
+   },
+   "paths":{
+      "/pets":{
+         "get":{
+            "parameters":[
+               {
+                  "$ref":"#/parameters/limit"
+               },
+               {
+                  "name":"tags",
+                  "in":"query",
+                  "type":"array",
+                  "items":{
+                     "type":"string"
+                  }
+               }
+            ],
+            "responses":{
+               "200":{
+                  "description":"",
+                  "schema":{
+                     "type":"array",
+                     "items":{
+                        "$ref":"#/definitions/Pet"
+                     }
+                  }
+               }
+            }
+         },
+         "post":{
+            "parameters":[
+               {
+                  "name":"body",
+                  "in":"body",
+                  "required":true,
+                  "schema":{
+                     "$ref":"#/definitions/Pet"
+                  }
+               }
+            ],
+            "responses":{
+               "204":{
+                  "description":""
+               }
+            }
+         }
+      },
+      "/pets/{petId}/image":{
+         "parameters":[
+            {
+               "name":"petId",
+               "in":"path",
+               "required":true,
+               "type":"integer"
+            }
+         ],
+         "post":{
+            "consumes":[
+               "multipart/form-data"
+            ],
+            "parameters":[
+               {
+                  "name":"file",
+                  "in":"formData",
+                  "type":"file"
+               }
+            ],
+            "responses":{
+               "204":{
+                  "description":""
+               }
+            }
+         }
+      }
+   },
+   "definitions":{
+      "Pet":{
+         "type":"object",
+         "properties":{
+            "name":{
+               "type":"string"
+            },
+            "tags":{
+               "type":"array",
+               "items":{
+                  "$ref":"#/definitions/Tag"
+               }
+            }
+         }
+      },
+      "Tag":{
+         "type":"object",
+         "properties":{
+            "name":{
+               "type":"string"
+            }
+         }
+      }
+   }
+}
+`
+
+const externalSpec = `
+openapi: 3.0.1
+info:
+  title: test
+  version: ""
+paths:
+  /users:
+    get:
+      parameters:
+        - $ref: ./params.yaml#/Limit
+        - $ref: '#/components/parameters/Offset'
+      responses:
+        200:
+          description: ""
+          content:
+            application/json:
+              schema:
+                type: array
+                items:
+                  $ref: ./schemas/user.yaml#/User
+components:
+  parameters:
+    Offset:
+      name: offset
+      in: query
+      schema:
+        type: integer
+  schemas:
+    User:
+      $ref: ./schemas/user.yaml#/User
+    Address:
+      type: object
+      properties:
+        street:
+          type: string
+`
+
+const yamlCall = `package blub
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestCall(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var pet Pet
+		if err := json.NewDecoder(r.Body).Decode(&pet); err != nil {
+			t.Error(err)
+		}
+
+		if r.Method != http.MethodPut || r.URL.Path != "/pets/7" || pet.Name != "rex" {
+			t.Errorf("unexpected %s %s with %+v", r.Method, r.URL.Path, pet)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	u, _ := url.Parse(srv.URL)
+	done := make(chan struct{})
+	NewTestService(u, "", nil).DefaultService().PutPetsPetId(context.Background(), 7, Pet{Name: "rex"}, func(err error) {
+		defer close(done)
+		if err != nil {
+			t.Error(err)
+		}
+	})
+	<-done
+}
+`
+
+const yamlSpec = `
+openapi: 3.0.1
+info:
+  title: test
+  version: ""
+paths:
+  /pets:
+    get:
+      responses:
+        200:
+          description: ""
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/Pet'
+  /pets/{petId}:
+    put:
+      parameters:
+        - name: petId
+          in: path
+          required: true
+          schema:
+            type: integer
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              $ref: '#/components/schemas/Pet'
+      responses:
+        204:
+          description: ""
+components:
+  schemas:
+    Named: &named
+      type: object
+      properties:
+        name:
+          type: string
+    Pet:
+      <<: *named
+      properties:
+        name:
+          type: string
+        tag:
+          type: string
+        born:
+          type: string
+          example: 2020-01-01
+`
+
+const unsupportedSpec = `{
+   "openapi":"3.0.1",
+   "info":{
+      "title":"test",
+      "version":""
+   },
+   "paths":{
+      "/holders":{
+         "get":{
+            "responses":{
+               "200":{
+                  "description":"",
+                  "content":{
+                     "application/json":{
+                        "schema":{
+                           "$ref":"#/components/schemas/Holder"
+                        }
+                     }
+                  }
+               }
+            }
+         }
+      },
+      "/items":{
+         "get":{
+            "parameters":[
+               {
+                  "name":"ids",
+                  "in":"query",
+                  "schema":{
+                     "type":"array"
+                  }
+               }
+            ],
+            "responses":{
+               "204":{
+                  "description":""
+               }
+            }
+         }
+      },
+      "/fine":{
+         "get":{
+            "responses":{
+               "204":{
+                  "description":""
+               }
+            }
+         }
+      }
+   },
+   "components":{
+      "schemas":{
+         "Holder":{
+            "type":"object",
+            "properties":{
+               "inner":{
+                  "type":"object",
+                  "properties":{
+                     "x":{
+                        "type":"tuple"
+                     }
+                  }
+               }
+            }
+         }
+      }
+   }
+}
+`
+
+const recursiveSpec = `{
+   "openapi":"3.0.1",
+   "info":{
+      "title":"test",
+      "version":""
+   },
+   "paths":{},
+   "components":{
+      "schemas":{
+         "Category":{
+            "type":"object",
+            "required":["parent"],
+            "properties":{
+               "parent":{
+                  "$ref":"#/components/schemas/Category"
+               }
+            }
+         },
+         "Node":{
+            "type":"object",
+            "required":["children", "next"],
+            "properties":{
+               "children":{
+                  "type":"array",
+                  "items":{
+                     "$ref":"#/components/schemas/Node"
+                  }
+               },
+               "next":{
+                  "$ref":"#/components/schemas/Node"
+               }
+            }
+         },
+         "A":{
+            "type":"object",
+            "required":["b"],
+            "properties":{
+               "b":{
+                  "$ref":"#/components/schemas/B"
+               }
+            }
+         },
+         "B":{
+            "type":"object",
+            "required":["a", "leaf"],
+            "properties":{
+               "a":{
+                  "$ref":"#/components/schemas/A"
+               },
+               "leaf":{
+                  "$ref":"#/components/schemas/Leaf"
+               }
+            }
+         },
+         "Leaf":{
+            "type":"object",
+            "properties":{
+               "name":{
+                  "type":"string"
+               }
+            }
+         }
+      }
+   }
+}
+`
+
+const namedSpec = `{
+   "openapi":"3.0.1",
+   "info":{
+      "title":"test",
+      "version":""
+   },
+   "paths":{},
+   "components":{
+      "schemas":{
+         "Id":{
+            "type":"string",
+            "description":"Id identifies a resource."
+         },
+         "Tags":{
+            "type":"array",
+            "items":{
+               "type":"object",
+               "properties":{
+                  "key":{
+                     "type":"string"
+                  }
+               }
+            }
+         },
+         "Active":{
+            "type":"boolean"
+         },
+         "Created":{
+            "type":"string",
+            "format":"date-time"
+         },
+         "Owner":{
+            "$ref":"#/components/schemas/Id"
+         }
+      }
+   }
+}
+`
+
+const mappingSpec = `{
+   "openapi":"3.0.1",
+   "info":{
+      "title":"test",
+      "version":""
+   },
+   "paths":{
+      "/orders":{
+         "get":{
+            "responses":{
+               "200":{
+                  "description":"",
+                  "content":{
+                     "application/json":{
+                        "schema":{
+                           "type":"object",
+                           "properties":{
+                              "count":{
+                                 "type":"integer"
+                              }
+                           }
+                        }
+                     }
+                  }
+               }
+            }
+         }
+      }
+   },
+   "components":{
+      "schemas":{
+         "Money":{
+            "type":"object",
+            "properties":{
+               "amount":{
+                  "type":"string"
+               }
+            }
+         },
+         "Order":{
+            "type":"object",
+            "required":["id", "total", "shipping", "color", "contact", "price"],
+            "properties":{
+               "id":{
+                  "type":"string",
+                  "x-ee.type":"github.com/golangee/uuid#UUID"
+               },
+               "total":{
+                  "$ref":"#/components/schemas/Money"
+               },
+               "shipping":{
+                  "type":"object",
+                  "properties":{
+                     "street":{
+                        "type":"string"
+                     }
+                  }
+               },
+               "color":{
+                  "type":"string",
+                  "x-ee.type":"color"
+               },
+               "contact":{
+                  "type":"string",
+                  "format":"email"
+               },
+               "price":{
+                  "type":"string"
+               }
+            }
+         }
+      }
+   }
+}
+`
+
+const binarySpec = `{
+   "openapi":"3.0.1",
+   "info":{
+      "title":"test",
+      "version":""
+   },
+   "paths":{
+      "/files":{
+         "post":{
+            "requestBody":{
+               "content":{
+                  "application/octet-stream":{
+                     "schema":{
+                        "type":"string",
+                        "format":"binary"
+                     }
+                  }
+               }
+            },
+            "responses":{
+               "200":{
+                  "description":"",
+                  "content":{
+                     "application/json":{
+                        "schema":{
+                           "$ref":"#/components/schemas/File"
+                        }
+                     }
+                  }
+               }
+            }
+         }
+      }
+   },
+   "components":{
+      "schemas":{
+         "File":{
+            "type":"object",
+            "required":["content"],
+            "properties":{
+               "content":{
+                  "type":"string",
+                  "format":"binary"
+               }
+            }
+         }
+      }
+   }
+}
+`
+
+const formatSpec = `{
+   "openapi":"3.0.1",
+   "info":{
+      "title":"test",
+      "version":""
+   },
+   "paths":{},
+   "components":{
+      "schemas":{
+         "Account":{
+            "type":"object",
+            "required":["id", "count", "ratio", "createdAt", "birthday", "avatar"],
+            "properties":{
+               "id":{
+                  "type":"string",
+                  "format":"uuid"
+               },
+               "count":{
+                  "type":"integer",
+                  "format":"int64"
+               },
+               "ratio":{
+                  "type":"number",
+                  "format":"float"
+               },
+               "createdAt":{
+                  "type":"string",
+                  "format":"date-time"
+               },
+               "birthday":{
+                  "type":"string",
+                  "format":"date"
+               },
+               "avatar":{
+                  "type":"string",
+                  "format":"byte"
+               }
+            }
+         }
+      }
+   }
+}
+`
+
+const inlineSpec = `{
+   "openapi":"3.0.1",
+   "info":{
+      "title":"test",
+      "version":""
+   },
+   "paths":{
+      "/users":{
+         "get":{
+            "responses":{
+               "200":{
+                  "description":"",
+                  "content":{
+                     "application/json":{
+                        "schema":{
+                           "type":"array",
+                           "items":{
+                              "type":"object",
+                              "properties":{
+                                 "id":{
+                                    "type":"string"
+                                 }
+                              }
+                           }
+                        }
+                     }
+                  }
+               }
+            }
+         },
+         "post":{
+            "requestBody":{
+               "content":{
+                  "application/json":{
+                     "schema":{
+                        "type":"object",
+                        "properties":{
+                           "name":{
+                              "type":"string"
+                           }
+                        }
+                     }
+                  }
+               }
+            },
+            "responses":{
+               "204":{
+                  "description":""
+               }
+            }
+         }
+      }
+   },
+   "components":{
+      "schemas":{
+         "User":{
+            "type":"object",
+            "properties":{
+               "address":{
+                  "type":"object",
+                  "properties":{
+                     "street":{
+                        "type":"string"
+                     }
+                  }
+               }
+            }
+         }
+      }
+   }
+}
+`
+
+const nullableAllOfSpec = `{
+   "openapi":"3.0.1",
+   "info":{
+      "title":"test",
+      "version":""
+   },
+   "paths":{},
+   "components":{
+      "schemas":{
+         "Customer":{
+            "type":"object",
+            "properties":{
+               "name":{
+                  "type":"string"
+               }
+            }
+         },
+         "Order":{
+            "type":"object",
+            "required":["customer"],
+            "properties":{
+               "customer":{
+                  "nullable":true,
+                  "allOf":[
+                     {
+                        "$ref":"#/components/schemas/Customer"
+                     }
+                  ]
+               },
+               "billing":{
+                  "description":"Billing is the invoiced customer.",
+                  "allOf":[
+                     {
+                        "$ref":"#/components/schemas/Customer"
+                     }
+                  ]
+               }
+            }
+         }
+      }
+   }
+}
+`
+
+const mapSpec = `{
+   "openapi":"3.0.1",
+   "info":{
+      "title":"test",
+      "version":""
+   },
+   "paths":{},
+   "components":{
+      "schemas":{
+         "Labels":{
+            "type":"object",
+            "additionalProperties":{
+               "type":"string"
+            }
+         },
+         "Anything":{
+            "type":"object"
+         },
+         "Tagged":{
+            "type":"object",
+            "properties":{
+               "name":{
+                  "type":"string"
+               }
+            },
+            "additionalProperties":{
+               "type":"integer"
+            }
+         }
+      }
+   }
+}
+`
+
+const allOfSpec = `{
+   "openapi":"3.0.1",
+   "info":{
+      "title":"test",
+      "version":""
+   },
+   "paths":{},
+   "components":{
+      "schemas":{
+         "User":{
+            "type":"object",
+            "required":["name"],
+            "properties":{
+               "name":{
+                  "type":"string"
+               }
+            }
+         },
+         "Admin":{
+            "allOf":[
+               {
+                  "$ref":"#/components/schemas/User"
+               },
+               {
+                  "type":"object",
+                  "properties":{
+                     "level":{
+                        "type":"integer"
+                     }
+                  }
+               }
+            ]
+         },
+         "Broken":{
+            "allOf":[
+               {
+                  "$ref":"#/components/schemas/User"
+               },
+               {
+                  "type":"object",
+                  "properties":{
+                     "name":{
+                        "type":"integer"
+                     }
+                  }
+               }
+            ]
+         }
+      }
+   }
+}
+`
+
+const unionSpec = `{
+   "openapi":"3.0.1",
+   "info":{
+      "title":"test",
+      "version":""
+   },
+   "paths":{},
+   "components":{
+      "schemas":{
+         "Cat":{
+            "type":"object",
+            "properties":{
+               "kind":{
+                  "type":"string"
+               }
+            }
+         },
+         "Dog":{
+            "type":"object",
+            "properties":{
+               "kind":{
+                  "type":"string"
+               }
+            }
+         },
+         "Pet":{
+            "oneOf":[
+               {
+                  "$ref":"#/components/schemas/Cat"
+               },
+               {
+                  "$ref":"#/components/schemas/Dog"
+               }
+            ],
+            "discriminator":{
+               "propertyName":"kind",
+               "mapping":{
+                  "cat":"Cat",
+                  "kitty":"#/components/schemas/Cat"
+               }
+            }
+         },
+         "Owner":{
+            "type":"object",
+            "properties":{
+               "contact":{
+                  "anyOf":[
+                     {
+                        "type":"string"
+                     },
+                     {
+                        "type":"integer"
+                     }
+                  ]
+               }
+            }
+         }
+      }
+   }
+}
+`
+
+const enumSpec = `{
+   "openapi":"3.0.1",
+   "info":{
+      "title":"test",
+      "version":""
+   },
+   "paths":{},
+   "components":{
+      "schemas":{
+         "State":{
+            "type":"string",
+            "enum":["ACTIVE","in-progress"]
+         },
+         "Color":{
+            "type":"string",
+            "nullable":true,
+            "enum":["red",null]
+         },
+         "Holiday":{
+            "type":"string",
+            "format":"date",
+            "enum":["2020-12-24"]
+         },
+         "Task":{
+            "type":"object",
+            "properties":{
+               "priority":{
+                  "type":"integer",
+                  "enum":[-1,0,1]
+               },
+               "color":{
+                  "$ref":"#/components/schemas/Color"
+               }
+            }
+         }
+      }
+   }
+}
+`
+
+const optionalSpec = `{
+   "openapi":"3.0.1",
+   "info":{
+      "title":"test",
+      "version":""
+   },
+   "paths":{},
+   "components":{
+      "schemas":{
+         "Person":{
+            "type":"object",
+            "required":["name","nick"],
+            "properties":{
+               "name":{
+                  "type":"string"
+               },
+               "nick":{
+                  "type":"string",
+                  "nullable":true
+               },
+               "age":{
+                  "type":"integer"
+               },
+               "tags":{
+                  "type":"array",
+                  "items":{
+                     "type":"string"
+                  }
+               }
+            }
+         }
+      }
+   }
 }
+`
 
 const spec = `{
    "openapi":"3.0.1",
@@ -62,6 +2969,35 @@ const spec = `{
                   }
                }
             }
+         },
+         "post":{
+            "tags":[
+               "setup"
+            ],
+            "summary":"Apply updates the current setup status",
+            "description":"Apply updates the current setup status.",
+            "requestBody":{
+               "required":true,
+               "content":{
+                  "application/json":{
+                     "schema":{
+                        "$ref":"#/components/schemas/Status"
+                     }
+                  }
+               }
+            },
+            "responses":{
+               "200":{
+                  "description":"Status represents the current setup status.",
+                  "content":{
+                     "application/json":{
+                        "schema":{
+                           "$ref":"#/components/schemas/Status"
+                        }
+                     }
+                  }
+               }
+            }
          }
       }
    },
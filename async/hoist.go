@@ -0,0 +1,218 @@
+// Copyright 2020 Torben Schinke
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package async
+
+import (
+	"github.com/golangee/openapi-client/internal/gen"
+	v3 "github.com/golangee/openapi/v3"
+	"reflect"
+	"strconv"
+)
+
+// hoistInlineSchemas moves each inline schema which requires a named Go type into the component schemas and
+// replaces it by a reference. The names are derived deterministically from the parents, e.g. UserStatus for the
+// property status of User, UsersStatus for the parameter status of the Users operation, CreateUsersRequest for its
+// request body, UsersResponse for its success response or UsersNotFoundResponse for its 404 response. Returns the
+// original JSON pointer of each new component.
+func hoistInlineSchemas(opts Options, doc *v3.Document) map[string]string {
+	if doc.Components == nil {
+		doc.Components = &v3.Components{}
+	}
+
+	if doc.Components.Schemas == nil {
+		doc.Components.Schemas = map[string]v3.Schema{}
+	}
+
+	origins := map[string]string{}
+	for _, name := range gen.SortedKeys(doc.Components.Schemas) {
+		if _, ok := findTypeMapping(opts, componentPointer(name), doc.Components.Schemas[name]); !ok {
+			hoistChildren(opts, doc, origins, name, componentPointer(name), doc.Components.Schemas[name])
+		}
+	}
+
+	for _, path := range gen.SortedKeys(doc.Paths) {
+		ops := doc.Paths[path].Map()
+		for _, method := range gen.SortedKeys(ops) {
+			ep := endpoint{path, method, ops[method]}
+			ptr := operationPointer(ep)
+			for i, param := range ep.op.Parameters {
+				name := methodName(ep) + gen.Identifier(param.Name)
+				paramPtr := ptr + "/parameters/" + strconv.Itoa(i) + "/schema"
+				ep.op.Parameters[i].Schema = hoistSchema(opts, doc, origins, name, paramPtr, param.Schema)
+			}
+
+			if ep.op.RequestBody != nil {
+				hoistContent(opts, doc, origins, methodName(ep)+"Request", ptr+"/requestBody", ep.op.RequestBody.Content)
+			}
+
+			successCode, _ := pickSuccessResponse(ep.op)
+			for _, code := range gen.SortedKeys(ep.op.Responses) {
+				name := methodName(ep) + responseName(code) + "Response"
+				if code == successCode {
+					name = methodName(ep) + "Response"
+				}
+				responsePtr := ptr + "/responses/" + gen.EscapeToken(code)
+				hoistContent(opts, doc, origins, name, responsePtr, ep.op.Responses[code].Content)
+			}
+		}
+	}
+
+	return origins
+}
+
+// hoistContent hoists the schema of the media type, which is picked for generation.
+func hoistContent(opts Options, doc *v3.Document, origins map[string]string, name, pointer string, content map[string]v3.MediaType) {
+	mediaType := pickMediaType(content)
+	if mediaType == "" {
+		return
+	}
+
+	media := content[mediaType]
+	media.Schema = hoistSchema(opts, doc, origins, name, pointer+"/content/"+gen.EscapeToken(mediaType)+"/schema", media.Schema)
+	content[mediaType] = media
+}
+
+// responseName returns an identifier for the response code, e.g. NotFound for 404 or ClientError for 4XX.
+func responseName(code string) string {
+	switch code {
+	case "4XX":
+		return "ClientError"
+	case "5XX":
+		return "ServerError"
+	case "default":
+		return "Default"
+	default:
+		return statusName(code)
+	}
+}
+
+// hoistChildren hoists the inline properties, array items and the inline oneOf or anyOf variants of the named
+// schema. Variants are named by their position, e.g. PetOption1.
+func hoistChildren(opts Options, doc *v3.Document, origins map[string]string, parent, pointer string, schema v3.Schema) {
+	for _, property := range gen.SortedKeys(schema.Properties) {
+		name := parent + gen.Identifier(property)
+		propPtr := pointer + "/properties/" + gen.EscapeToken(property)
+		schema.Properties[property] = hoistSchema(opts, doc, origins, name, propPtr, schema.Properties[property])
+	}
+
+	for kind, variants := range [][]v3.Schema{schema.OneOf, schema.AnyOf} {
+		for i, variant := range variants {
+			name := parent + "Option" + strconv.Itoa(i+1)
+			variantPtr := pointer + "/" + []string{"oneOf", "anyOf"}[kind] + "/" + strconv.Itoa(i)
+			variants[i] = hoistSchema(opts, doc, origins, name, variantPtr, variant)
+		}
+	}
+
+	if schema.AdditionalProperties != nil && schema.AdditionalProperties.Schema != nil {
+		value := hoistSchema(opts, doc, origins, parent+"Value", pointer+"/additionalProperties", *schema.AdditionalProperties.Schema)
+		schema.AdditionalProperties.Schema = &value
+	}
+
+	if schema.Items != nil && schema.Items.Schema != nil {
+		items := hoistSchema(opts, doc, origins, parent+"Item", pointer+"/items", *schema.Items.Schema)
+		schema.Items.Schema = &items
+	}
+
+	// inline allOf members are flattened into their parent, so their properties are named like their own
+	for i, member := range schema.AllOf {
+		if member.Ref == nil {
+			hoistChildren(opts, doc, origins, parent, pointer+"/allOf/"+strconv.Itoa(i), member)
+		}
+	}
+}
+
+// hoistSchema returns either the schema itself or a reference to the new component, if it requires a named type.
+// The items of an inline array are hoisted using the Item suffix and the values of an inline map using the Value
+// suffix. Schemas which are mapped to existing types are kept as they are, unless they are mapped by their pointer.
+// These become components, whose children are not hoisted, so that their mapping is found by the pointer of the
+// component, see resolveTypeMappings.
+func hoistSchema(opts Options, doc *v3.Document, origins map[string]string, name, pointer string, schema v3.Schema) v3.Schema {
+	if _, ok := pointerMapping(opts, pointer); ok {
+		_, ref := hoistComponent(doc, origins, name, pointer, schema)
+		return ref
+	}
+
+	if _, ok := findTypeMapping(opts, "", schema); ok || schema.Ref != nil {
+		return schema
+	}
+
+	if ref, ok := singleRefAllOf(schema); ok {
+		return v3.Schema{Ref: ref, Description: schema.Description, Nullable: schema.Nullable}
+	}
+
+	if schema.Type == v3.Array && schema.Items != nil && schema.Items.Schema != nil {
+		items := hoistSchema(opts, doc, origins, name+"Item", pointer+"/items", *schema.Items.Schema)
+		schema.Items = &v3.Items{Schema: &items}
+		return schema
+	}
+
+	if !requiresNamedType(schema) {
+		if schema.AdditionalProperties != nil && schema.AdditionalProperties.Schema != nil {
+			value := hoistSchema(opts, doc, origins, name+"Value", pointer+"/additionalProperties", *schema.AdditionalProperties.Schema)
+			schema.AdditionalProperties = &v3.AdditionalProperties{Allowed: true, Schema: &value}
+		}
+		return schema
+	}
+
+	name, ref := hoistComponent(doc, origins, name, pointer, schema)
+	hoistChildren(opts, doc, origins, name, pointer, schema)
+	return ref
+}
+
+// hoistComponent adds the schema as component under a unique name, which is returned with the reference to it.
+// The original pointer is recorded in the origins.
+func hoistComponent(doc *v3.Document, origins map[string]string, name, pointer string, schema v3.Schema) (string, v3.Schema) {
+	name = uniqueComponentName(doc, name)
+	doc.Components.Schemas[name] = schema
+	origins[name] = pointer
+	ref := gen.ComponentSchemaPrefix + name
+	return name, v3.Schema{Ref: &ref, Description: schema.Description, Nullable: schema.Nullable}
+}
+
+// singleRefAllOf returns the reference of an allOf with a single referencing member and nothing else, which is
+// commonly used to add a description or nullable to a reference. Such a schema is the referenced type itself.
+func singleRefAllOf(schema v3.Schema) (*string, bool) {
+	if len(schema.AllOf) != 1 || schema.AllOf[0].Ref == nil {
+		return nil, false
+	}
+
+	rest := schema
+	rest.AllOf, rest.Description, rest.Nullable = nil, "", false
+	if rest.Type == v3.Object {
+		rest.Type = ""
+	}
+
+	if !reflect.DeepEqual(rest, v3.Schema{}) {
+		return nil, false
+	}
+	return schema.AllOf[0].Ref, true
+}
+
+// requiresNamedType checks if the schema cannot be expressed by an anonymous Go type.
+func requiresNamedType(schema v3.Schema) bool {
+	return len(schema.Enum) > 0 || isUnion(schema) || len(schema.AllOf) > 0 ||
+		(isObject(schema) && len(schema.Properties) > 0)
+}
+
+// uniqueComponentName returns name or, if already taken, name with a numbered suffix.
+func uniqueComponentName(doc *v3.Document, name string) string {
+	tmp := name
+	for i := 2; ; i++ {
+		if _, taken := doc.Components.Schemas[tmp]; !taken {
+			return tmp
+		}
+		tmp = name + strconv.Itoa(i)
+	}
+}
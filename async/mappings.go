@@ -0,0 +1,215 @@
+// Copyright 2020 Torben Schinke
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package async
+
+import (
+	"fmt"
+	"github.com/golangee/openapi-client/internal/gen"
+	v3 "github.com/golangee/openapi/v3"
+	"strings"
+)
+
+// validateTypeMappings checks that each mapping has a matcher and valid type references.
+func validateTypeMappings(opts Options) error {
+	adapters := map[string]TypeMapping{}
+	for i, m := range opts.TypeMappings {
+		if m.Pointer == "" && m.Component == "" && m.XType == "" && m.Type == "" {
+			return fmt.Errorf("type mapping %d: one of Pointer, Component, XType or Type is required", i)
+		}
+
+		if m.Format != "" && m.Type == "" {
+			return fmt.Errorf("type mapping %d: Format requires a Type", i)
+		}
+
+		for _, ref := range []string{m.GoType, m.Marshal, m.Unmarshal} {
+			if strings.HasPrefix(ref, "#") || strings.HasSuffix(ref, "#") {
+				return fmt.Errorf("type mapping %d: invalid type reference '%s'", i, ref)
+			}
+		}
+
+		if m.GoType == "" {
+			return fmt.Errorf("type mapping %d: GoType is required", i)
+		}
+
+		if (m.Marshal == "") != (m.Unmarshal == "") {
+			return fmt.Errorf("type mapping %d: Marshal and Unmarshal must be declared together", i)
+		}
+
+		if m.Marshal != "" {
+			name := adapterName(m)
+			if other, has := adapters[name]; has && (other.GoType != m.GoType || other.Marshal != m.Marshal || other.Unmarshal != m.Unmarshal) {
+				return fmt.Errorf("type mapping %d: adapter %s is already declared for other functions", i, name)
+			}
+			adapters[name] = m
+		}
+	}
+	return nil
+}
+
+// checkTypeMappings ensures that each mapping by pointer or component name matches a schema of the document. This
+// must happen before hoisting, because hoisting changes the pointers of inline schemas.
+func checkTypeMappings(opts Options, doc *v3.Document) error {
+	pointers := map[string]bool{}
+	for _, m := range opts.TypeMappings {
+		if m.Pointer != "" {
+			pointers[normalizePointer(m.Pointer)] = true
+		} else if m.Component != "" {
+			pointers[componentPointer(m.Component)] = true
+		}
+	}
+
+	if len(pointers) == 0 {
+		return nil
+	}
+
+	found := map[string]bool{}
+	rewriteSchemas(doc, func(pointer string, schema v3.Schema) v3.Schema {
+		found[pointer] = pointers[pointer]
+		return schema
+	})
+
+	for _, pointer := range gen.SortedKeys(pointers) {
+		if !found[pointer] {
+			return fmt.Errorf("type mapping for %s does not match any schema", pointer)
+		}
+	}
+	return nil
+}
+
+// resolveTypeMappings returns the mappings, whose pointers to hoisted schemas have been replaced by the pointers
+// of their new components. Inline schemas which are mapped by pointer are always hoisted, see hoistSchema, so that
+// afterwards only component schemas are matched by pointer or component name.
+func resolveTypeMappings(opts Options, origins map[string]string) []TypeMapping {
+	hoisted := map[string]string{}
+	for name, origin := range origins {
+		hoisted[origin] = componentPointer(name)
+	}
+
+	res := make([]TypeMapping, len(opts.TypeMappings))
+	for i, m := range opts.TypeMappings {
+		if pointer, has := hoisted[normalizePointer(m.Pointer)]; m.Pointer != "" && has {
+			m.Pointer = pointer
+		}
+		res[i] = m
+	}
+	return res
+}
+
+// pointerMapping returns the mapping which matches the JSON pointer, either by Pointer or by Component. A pointer
+// takes precedence over a component name.
+func pointerMapping(opts Options, pointer string) (TypeMapping, bool) {
+	for _, m := range opts.TypeMappings {
+		if m.Pointer != "" && normalizePointer(m.Pointer) == pointer {
+			return m, true
+		}
+	}
+
+	for _, m := range opts.TypeMappings {
+		if m.Pointer == "" && m.Component != "" && componentPointer(m.Component) == pointer {
+			return m, true
+		}
+	}
+	return TypeMapping{}, false
+}
+
+// findTypeMapping returns the mapping which applies to the schema at the JSON pointer or false, if a type has to be
+// generated. The pointer is only known for component schemas and empty otherwise. The pointer or component mappings
+// take precedence, then the x-ee.type is matched against the declared mappings and Options.UseReferences.
+// Primitive schemas are also matched by their type and format.
+func findTypeMapping(opts Options, pointer string, schema v3.Schema) (TypeMapping, bool) {
+	if pointer != "" {
+		if m, ok := pointerMapping(opts, pointer); ok {
+			return m, true
+		}
+	}
+
+	if schema.XType != nil {
+		xType := *schema.XType
+		for _, m := range opts.TypeMappings {
+			if m.XType != "" && m.XType == xType {
+				return m, true
+			}
+		}
+
+		for _, v := range opts.UseReferences {
+			if v == xType {
+				return TypeMapping{XType: v, GoType: v}, true
+			}
+		}
+	}
+
+	if schema.Ref == nil && schema.Type != "" {
+		for _, m := range opts.TypeMappings {
+			if m.Type == string(schema.Type) && m.Format == schema.Format {
+				return m, true
+			}
+		}
+	}
+
+	return TypeMapping{}, false
+}
+
+// mappedTypeName returns the adapter of the mapping or its Go type.
+func mappedTypeName(f *gen.GoGenFile, m TypeMapping) string {
+	if m.Marshal != "" {
+		return adapterName(m)
+	}
+	return goTypeName(f, m.GoType)
+}
+
+// adapterName derives the name of the generated adapter type from the mapped Go type, e.g. MoneyAdapter.
+func adapterName(m TypeMapping) string {
+	name := m.GoType
+	if idx := strings.LastIndex(name, "#"); idx >= 0 {
+		name = name[idx+1:]
+	}
+	return gen.Public(gen.Identifier(name)) + "Adapter"
+}
+
+// emitTypeAdapters generates a wrapper type for each mapping with custom marshal and unmarshal functions.
+func emitTypeAdapters(opts Options, f *gen.GoGenFile) {
+	emitted := map[string]bool{}
+	for _, m := range opts.TypeMappings {
+		if m.Marshal == "" || emitted[adapterName(m)] {
+			continue
+		}
+		emitted[adapterName(m)] = true
+
+		name := adapterName(m)
+		goType := goTypeName(f, m.GoType)
+		marshal := goTypeName(f, m.Marshal)
+		unmarshal := goTypeName(f, m.Unmarshal)
+
+		f.Printf("// %s converts %s from and to its json representation.\n", name, goType)
+		f.Printf("type %s struct {\n", name)
+		f.Printf("Value %s\n", goType)
+		f.Printf("}\n\n")
+
+		f.Printf("// MarshalJSON encodes the value using %s.\n", marshal)
+		f.Printf("func (a %s) MarshalJSON() ([]byte, error) {\n", name)
+		f.Printf("return %s(a.Value)\n", marshal)
+		f.Printf("}\n\n")
+
+		f.Printf("// UnmarshalJSON decodes the value using %s.\n", unmarshal)
+		f.Printf("func (a *%s) UnmarshalJSON(buf []byte) error {\n", name)
+		f.Printf("v, err := %s(buf)\n", unmarshal)
+		f.Printf("if err != nil {\n")
+		f.Printf("return err\n")
+		f.Printf("}\n")
+		f.Printf("a.Value = v\n")
+		f.Printf("return nil\n")
+		f.Printf("}\n\n")
+	}
+}
@@ -0,0 +1,142 @@
+// Copyright 2020 Torben Schinke
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package async
+
+import (
+	"github.com/golangee/openapi-client/internal/gen"
+	v3 "github.com/golangee/openapi/v3"
+	"strconv"
+	"strings"
+)
+
+// isObject checks if the schema is an object, either declared explicitly or implicitly by properties.
+func isObject(schema v3.Schema) bool {
+	if schema.Type == v3.Object {
+		return true
+	}
+	return schema.Type == "" && schema.Ref == nil && (len(schema.Properties) > 0 || schema.AdditionalProperties != nil)
+}
+
+// isAnySchema checks if the schema does not constrain the value at all, like {}.
+func isAnySchema(schema v3.Schema) bool {
+	return schema.Type == "" && schema.Ref == nil && len(schema.Properties) == 0 && schema.Items == nil &&
+		len(schema.Enum) == 0 && !isUnion(schema) && len(schema.AllOf) == 0 && schema.AdditionalProperties == nil
+}
+
+// additionalPropertiesTypeName returns the value type of the additional properties or false, if the object does
+// not allow them. Values without a schema are kept as raw json.
+func additionalPropertiesTypeName(opts Options, f *gen.GoGenFile, doc *v3.Document, schema v3.Schema) (string, bool, error) {
+	additional := schema.AdditionalProperties
+	if additional == nil || !additional.Allowed {
+		return "", false, nil
+	}
+
+	if additional.Schema == nil || isAnySchema(*additional.Schema) {
+		return f.ImportName("encoding/json", "RawMessage"), true, nil
+	}
+
+	tname, err := typeName(opts, f, doc, *additional.Schema)
+	if err != nil {
+		return "", false, diagnosticAt("/additionalProperties", err)
+	}
+	return tname, true, nil
+}
+
+// mapTypeName returns the map type of an object without declared properties. An object which does not declare
+// additional properties at all is free-form and keeps its values as raw json.
+func mapTypeName(opts Options, f *gen.GoGenFile, doc *v3.Document, schema v3.Schema) (string, bool, error) {
+	if len(schema.Properties) > 0 {
+		return "", false, nil
+	}
+
+	valueType, ok, err := additionalPropertiesTypeName(opts, f, doc, schema)
+	if err != nil {
+		return "", false, err
+	}
+
+	if ok {
+		return "map[string]" + valueType, true, nil
+	}
+
+	if schema.AdditionalProperties == nil {
+		return "map[string]" + f.ImportName("encoding/json", "RawMessage"), true, nil
+	}
+
+	return "", false, nil
+}
+
+// emitAdditionalPropertiesJSON generates the json methods for a struct with declared properties and a catch-all
+// field for additional properties.
+func emitAdditionalPropertiesJSON(f *gen.GoGenFile, name, fieldName, valueType string, properties []string) {
+	marshal := f.ImportName("encoding/json", "Marshal")
+	unmarshal := f.ImportName("encoding/json", "Unmarshal")
+	rawMessage := f.ImportName("encoding/json", "RawMessage")
+
+	f.Printf("// MarshalJSON encodes the declared properties and merges the additional properties.\n")
+	f.Printf("func (t %s) MarshalJSON() ([]byte, error) {\n", name)
+	f.Printf("type plain %s\n", name)
+	f.Printf("buf, err := %s(plain(t))\n", marshal)
+	f.Printf("if err != nil || len(t.%s) == 0 {\n", fieldName)
+	f.Printf("return buf, err\n")
+	f.Printf("}\n\n")
+	f.Printf("props := map[string]%s{}\n", rawMessage)
+	f.Printf("if err := %s(buf, &props); err != nil {\n", unmarshal)
+	f.Printf("return nil, err\n")
+	f.Printf("}\n")
+	f.Printf("for k, v := range t.%s {\n", fieldName)
+	f.Printf("if _, declared := props[k]; declared {\n")
+	f.Printf("continue\n")
+	f.Printf("}\n")
+	f.Printf("raw, err := %s(v)\n", marshal)
+	f.Printf("if err != nil {\n")
+	f.Printf("return nil, err\n")
+	f.Printf("}\n")
+	f.Printf("props[k] = raw\n")
+	f.Printf("}\n")
+	f.Printf("return %s(props)\n", marshal)
+	f.Printf("}\n\n")
+
+	var literals []string
+	for _, property := range properties {
+		literals = append(literals, strconv.Quote(property))
+	}
+
+	f.Printf("// UnmarshalJSON decodes the declared properties and collects all others as additional properties.\n")
+	f.Printf("func (t *%s) UnmarshalJSON(buf []byte) error {\n", name)
+	f.Printf("type plain %s\n", name)
+	f.Printf("if err := %s(buf, (*plain)(t)); err != nil {\n", unmarshal)
+	f.Printf("return err\n")
+	f.Printf("}\n\n")
+	f.Printf("props := map[string]%s{}\n", rawMessage)
+	f.Printf("if err := %s(buf, &props); err != nil {\n", unmarshal)
+	f.Printf("return err\n")
+	f.Printf("}\n")
+	f.Printf("for _, k := range []string{%s} {\n", strings.Join(literals, ","))
+	f.Printf("delete(props, k)\n")
+	f.Printf("}\n\n")
+	f.Printf("t.%s = nil\n", fieldName)
+	f.Printf("for k, raw := range props {\n")
+	f.Printf("var v %s\n", valueType)
+	f.Printf("if err := %s(raw, &v); err != nil {\n", unmarshal)
+	f.Printf("return err\n")
+	f.Printf("}\n")
+	f.Printf("if t.%s == nil {\n", fieldName)
+	f.Printf("t.%s = map[string]%s{}\n", fieldName, valueType)
+	f.Printf("}\n")
+	f.Printf("t.%s[k] = v\n", fieldName)
+	f.Printf("}\n")
+	f.Printf("return nil\n")
+	f.Printf("}\n\n")
+}
@@ -0,0 +1,424 @@
+// Copyright 2020 Torben Schinke
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package async
+
+import (
+	"fmt"
+	"github.com/golangee/openapi-client/internal/gen"
+	v3 "github.com/golangee/openapi/v3"
+	"go/token"
+	"go/types"
+	"strings"
+)
+
+// reservedParamNames contains the locals and arguments of the generated calls and the package level declarations
+// of the stubs, which must not be shadowed by a parameter.
+var reservedParamNames = []string{
+	"_ctx", "_self", "path", "_query", "_body", "_form", "_contentType", "_err", "_req", "_res", "_buf", "body", "f",
+	"res", "err", "paramKind", "paramPrimitive", "paramArray", "paramObject", "decomposeParam", "formatPrimitive", "joinParam",
+	"formatSimple", "encodePathParam", "queryParamPairs", "addQueryParam", "formFields", "encodeForm",
+	"encodeMultipart", "maxErrorBodySize",
+}
+
+// paramName converts the name of a parameter (like X-Tenant-ID or type) into a valid local Go identifier.
+func paramName(name string) string {
+	res := gen.Private(gen.Identifier(name))
+	if token.Lookup(res).IsKeyword() {
+		return res + "_"
+	}
+	return res
+}
+
+// paramNames returns the local Go identifiers of all parameters of the endpoint in declaration order. A name
+// which collides with another parameter, a reserved name, an imported package or a predeclared identifier gets a
+// numbered suffix.
+func paramNames(f *gen.GoGenFile, ep endpoint) []string {
+	usedNames := map[string]bool{errorParserName(ep): true}
+	for _, name := range reservedParamNames {
+		usedNames[name] = true
+	}
+
+	res := make([]string, 0, len(ep.op.Parameters))
+	for _, p := range ep.op.Parameters {
+		name := paramName(p.Name)
+		if f.HasImportAlias(name) || types.Universe.Lookup(name) != nil {
+			usedNames[name] = true
+		}
+		res = append(res, uniqueName(usedNames, name))
+	}
+	return res
+}
+
+// optionalParamType returns a pointer to the type of an optional parameter, so that zero values like false or 0
+// can be sent and only nil means absent. Slices and maps are nil-able already.
+func optionalParamType(p v3.Parameter, tname string) string {
+	if p.Required || p.In == v3.PathLocation || strings.HasPrefix(tname, "[]") || strings.HasPrefix(tname, "map[") {
+		return tname
+	}
+	return "*" + tname
+}
+
+// paramStyle returns the declared style and explode setting of the parameter or the defaults of its location.
+func paramStyle(p v3.Parameter) (style string, explode bool) {
+	style = p.Style
+	if style == "" {
+		switch p.In {
+		case v3.QueryLocation, v3.CookieLocation:
+			style = "form"
+		default:
+			style = "simple"
+		}
+	}
+
+	explode = style == "form"
+	if p.Explode != nil {
+		explode = *p.Explode
+	}
+	return style, explode
+}
+
+// findParam returns the index of the parameter declared by name and location or -1 and a parameter with defaults,
+// if not declared.
+func findParam(ep endpoint, in v3.Location, name string) (int, v3.Parameter) {
+	for i, p := range ep.op.Parameters {
+		if p.In == in && p.Name == name {
+			return i, p
+		}
+	}
+	return -1, v3.Parameter{Name: name, In: in}
+}
+
+// emitQueryParams declares _query and adds all query parameters to it. Returns the expression to pass to newRequest.
+func emitQueryParams(f *gen.GoGenFile, ep endpoint, names []string) string {
+	query := "nil"
+	for i, inParam := range ep.op.Parameters {
+		if inParam.In != v3.QueryLocation {
+			continue
+		}
+
+		if query == "nil" {
+			f.Printf("_query := %s{}\n", f.ImportName("net/url", "Values"))
+			query = "_query"
+		}
+
+		style, explode := paramStyle(inParam)
+		emitOptionalParam(f, inParam, names[i], fmt.Sprintf("addQueryParam(_query,%q,%q,%v,%s)\n", inParam.Name, style, explode, names[i]))
+	}
+	return query
+}
+
+// emitHeaderAndCookieParams sets all header and cookie parameters on the already created _req.
+func emitHeaderAndCookieParams(f *gen.GoGenFile, ep endpoint, names []string) {
+	for i, inParam := range ep.op.Parameters {
+		_, explode := paramStyle(inParam)
+		switch inParam.In {
+		case v3.HeaderLocation:
+			emitOptionalParam(f, inParam, names[i], fmt.Sprintf("_req.Header.Set(%q,formatSimple(%v,%s))\n", inParam.Name, explode, names[i]))
+		case v3.CookieLocation:
+			emitOptionalParam(f, inParam, names[i], fmt.Sprintf("_req.AddCookie(&%s{Name:%q,Value:formatSimple(%v,%s)})\n", f.ImportName("net/http", "Cookie"), inParam.Name, explode, names[i]))
+		}
+	}
+}
+
+// emitOptionalParam prints the statement and guards it, if the parameter is not required, so that unset optional
+// parameters are not sent at all. Optional parameters are nil, if unset, see optionalParamType.
+func emitOptionalParam(f *gen.GoGenFile, inParam v3.Parameter, name, stmt string) {
+	if inParam.Required {
+		f.Printf(stmt)
+		return
+	}
+
+	f.Printf("if %s != nil {\n", name)
+	f.Printf(stmt)
+	f.Printf("}\n")
+}
+
+const paramStub = `type paramKind int
+
+const (
+	paramPrimitive paramKind = iota
+	paramArray
+	paramObject
+)
+
+// decomposeParam flattens v into formatted but unescaped values. A primitive results in a single value, an array
+// in its elements and an object in alternating keys and values, sorted by key as defined by its json representation.
+// The numbers of an object keep their json text, so that large integers do not lose their precision.
+func decomposeParam(v interface{}) (paramKind, []string) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return paramPrimitive, []string{""}
+		}
+		rv = rv.Elem()
+	}
+
+	if !rv.IsValid() {
+		return paramPrimitive, []string{""}
+	}
+
+	if _, ok := rv.Interface().(encoding.TextMarshaler); ok {
+		return paramPrimitive, []string{formatPrimitive(rv.Interface())}
+	}
+
+	if buf, ok := rv.Interface().([]byte); ok {
+		return paramPrimitive, []string{base64.StdEncoding.EncodeToString(buf)}
+	}
+
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		res := make([]string, rv.Len())
+		for i := range res {
+			res[i] = formatPrimitive(rv.Index(i).Interface())
+		}
+		return paramArray, res
+	case reflect.Struct, reflect.Map:
+		buf, err := json.Marshal(rv.Interface())
+		if err != nil {
+			break
+		}
+		props := map[string]interface{}{}
+		dec := json.NewDecoder(bytes.NewReader(buf))
+		dec.UseNumber()
+		if err := dec.Decode(&props); err != nil {
+			break
+		}
+		keys := make([]string, 0, len(props))
+		for k := range props {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		res := make([]string, 0, len(keys)*2)
+		for _, k := range keys {
+			res = append(res, k, formatPrimitive(props[k]))
+		}
+		return paramObject, res
+	}
+
+	return paramPrimitive, []string{formatPrimitive(rv.Interface())}
+}
+
+// formatPrimitive formats a single value without escaping.
+func formatPrimitive(v interface{}) string {
+	switch t := v.(type) {
+	case string:
+		return t
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	case encoding.TextMarshaler:
+		buf, err := t.MarshalText()
+		if err == nil {
+			return string(buf)
+		}
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+// joinParam delimits arrays by sep, non-exploded objects by comma and exploded objects as key=value pairs by sep.
+func joinParam(kind paramKind, values []string, explode bool, sep string) string {
+	if kind != paramObject {
+		return strings.Join(values, sep)
+	}
+
+	if !explode {
+		return strings.Join(values, ",")
+	}
+
+	pairs := make([]string, 0, len(values)/2)
+	for i := 0; i+1 < len(values); i += 2 {
+		pairs = append(pairs, values[i]+"="+values[i+1])
+	}
+	return strings.Join(pairs, sep)
+}
+
+// formatSimple serializes v in the simple style, as required for header parameters. Cookies use the same
+// comma separated representation.
+func formatSimple(explode bool, v interface{}) string {
+	kind, values := decomposeParam(v)
+	return joinParam(kind, values, explode, ",")
+}
+
+// encodePathParam serializes and escapes v in the simple, label or matrix style.
+func encodePathParam(name, style string, explode bool, v interface{}) string {
+	kind, values := decomposeParam(v)
+	for i := range values {
+		values[i] = url.PathEscape(values[i])
+	}
+
+	switch style {
+	case "label":
+		sep := ","
+		if explode {
+			sep = "."
+		}
+		return "." + joinParam(kind, values, explode, sep)
+	case "matrix":
+		if kind == paramObject && explode {
+			return ";" + joinParam(kind, values, explode, ";")
+		}
+		sep := ","
+		if explode {
+			sep = ";" + name + "="
+		}
+		return ";" + name + "=" + joinParam(kind, values, explode, sep)
+	default:
+		return joinParam(kind, values, explode, ",")
+	}
+}
+
+// queryParamPairs serializes v into unescaped name/value pairs in the form, spaceDelimited, pipeDelimited or
+// deepObject style.
+func queryParamPairs(name, style string, explode bool, v interface{}) [][2]string {
+	kind, values := decomposeParam(v)
+	var res [][2]string
+	switch kind {
+	case paramArray:
+		if explode {
+			for _, value := range values {
+				res = append(res, [2]string{name, value})
+			}
+			return res
+		}
+
+		sep := ","
+		switch style {
+		case "spaceDelimited":
+			sep = " "
+		case "pipeDelimited":
+			sep = "|"
+		}
+		return append(res, [2]string{name, strings.Join(values, sep)})
+	case paramObject:
+		if style == "deepObject" || explode {
+			for i := 0; i+1 < len(values); i += 2 {
+				key := values[i]
+				if style == "deepObject" {
+					key = name + "[" + key + "]"
+				}
+				res = append(res, [2]string{key, values[i+1]})
+			}
+			return res
+		}
+		return append(res, [2]string{name, strings.Join(values, ",")})
+	default:
+		return append(res, [2]string{name, values[0]})
+	}
+}
+
+// addQueryParam serializes v like queryParamPairs and adds the result to the query.
+func addQueryParam(query url.Values, name, style string, explode bool, v interface{}) {
+	for _, pair := range queryParamPairs(name, style, explode, v) {
+		query.Add(pair[0], pair[1])
+	}
+}
+
+// formFields decomposes the json representation of the object v into form fields, whose values are serialized like
+// exploded form parameters. Byte slice fields of a struct are returned as files with their raw content.
+func formFields(v interface{}) ([][2]string, map[string][]byte, error) {
+	buf, err := json.Marshal(v)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	props := map[string]interface{}{}
+	dec := json.NewDecoder(bytes.NewReader(buf))
+	dec.UseNumber()
+	if err := dec.Decode(&props); err != nil {
+		return nil, nil, err
+	}
+
+	files := map[string][]byte{}
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if rv.Kind() == reflect.Struct {
+		for i := 0; i < rv.NumField(); i++ {
+			if !rv.Field(i).CanInterface() {
+				continue
+			}
+
+			name := strings.Split(rv.Type().Field(i).Tag.Get("json"), ",")[0]
+			content, ok := rv.Field(i).Interface().([]byte)
+			if _, has := props[name]; ok && has {
+				files[name] = content
+				delete(props, name)
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(props))
+	for k := range props {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var fields [][2]string
+	for _, k := range keys {
+		fields = append(fields, queryParamPairs(k, "form", true, props[k])...)
+	}
+	return fields, files, nil
+}
+
+// encodeForm encodes the object v as application/x-www-form-urlencoded.
+func encodeForm(v interface{}) (io.Reader, error) {
+	fields, files, err := formFields(v)
+	if err != nil {
+		return nil, err
+	}
+
+	values := url.Values{}
+	for _, field := range fields {
+		values.Add(field[0], field[1])
+	}
+	for name, content := range files {
+		values.Add(name, string(content))
+	}
+	return strings.NewReader(values.Encode()), nil
+}
+
+// encodeMultipart encodes the object v as multipart/form-data and returns the content type with its boundary.
+func encodeMultipart(v interface{}) (string, io.Reader, error) {
+	fields, files, err := formFields(v)
+	if err != nil {
+		return "", nil, err
+	}
+
+	body := &bytes.Buffer{}
+	w := multipart.NewWriter(body)
+	for _, field := range fields {
+		if err := w.WriteField(field[0], field[1]); err != nil {
+			return "", nil, err
+		}
+	}
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		part, err := w.CreateFormFile(name, name)
+		if err != nil {
+			return "", nil, err
+		}
+		if _, err := part.Write(files[name]); err != nil {
+			return "", nil, err
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		return "", nil, err
+	}
+	return w.FormDataContentType(), body, nil
+}
+`
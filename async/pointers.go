@@ -0,0 +1,116 @@
+// Copyright 2020 Torben Schinke
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package async
+
+import (
+	"github.com/golangee/openapi-client/internal/gen"
+	v3 "github.com/golangee/openapi/v3"
+	"strconv"
+	"strings"
+)
+
+// normalizePointer returns the pointer as URI fragment, like #/components/schemas/User.
+func normalizePointer(pointer string) string {
+	return "#" + strings.TrimSuffix(strings.TrimPrefix(pointer, "#"), "/")
+}
+
+// componentPointer returns the JSON pointer of the named component schema.
+func componentPointer(name string) string {
+	return gen.ComponentSchemaPrefix + gen.EscapeToken(name)
+}
+
+// operationPointer returns the JSON pointer of the endpoints operation, like #/paths/~1users/get.
+func operationPointer(ep endpoint) string {
+	return "#/paths/" + gen.EscapeToken(ep.path) + "/" + strings.ToLower(ep.method)
+}
+
+// rewriteSchemas calls fn for each schema of the document and replaces the schema by the result. Sub schemas
+// are visited after their parent, so that fn sees the rewritten parent first. References are not followed.
+func rewriteSchemas(doc *v3.Document, fn func(pointer string, schema v3.Schema) v3.Schema) {
+	if doc.Components != nil {
+		for _, name := range gen.SortedKeys(doc.Components.Schemas) {
+			doc.Components.Schemas[name] = rewriteSchema(componentPointer(name), doc.Components.Schemas[name], fn)
+		}
+	}
+
+	for _, path := range gen.SortedKeys(doc.Paths) {
+		ops := doc.Paths[path].Map()
+		for _, method := range gen.SortedKeys(ops) {
+			ep := endpoint{path: path, method: method, op: ops[method]}
+			ptr := operationPointer(ep)
+			for i, param := range ep.op.Parameters {
+				ep.op.Parameters[i].Schema = rewriteSchema(ptr+"/parameters/"+strconv.Itoa(i)+"/schema", param.Schema, fn)
+			}
+
+			if ep.op.RequestBody != nil {
+				rewriteContent(ptr+"/requestBody", ep.op.RequestBody.Content, fn)
+			}
+
+			for _, code := range gen.SortedKeys(ep.op.Responses) {
+				rewriteContent(ptr+"/responses/"+gen.EscapeToken(code), ep.op.Responses[code].Content, fn)
+			}
+		}
+	}
+}
+
+// rewriteContent rewrites the schemas of all media types.
+func rewriteContent(pointer string, content map[string]v3.MediaType, fn func(pointer string, schema v3.Schema) v3.Schema) {
+	for _, mediaType := range gen.SortedKeys(content) {
+		media := content[mediaType]
+		media.Schema = rewriteSchema(pointer+"/content/"+gen.EscapeToken(mediaType)+"/schema", media.Schema, fn)
+		content[mediaType] = media
+	}
+}
+
+// rewriteSchema rewrites the schema and then all of its inline sub schemas.
+func rewriteSchema(pointer string, schema v3.Schema, fn func(pointer string, schema v3.Schema) v3.Schema) v3.Schema {
+	schema = fn(pointer, schema)
+
+	if len(schema.Properties) > 0 {
+		props := make(map[string]v3.Schema, len(schema.Properties))
+		for _, name := range gen.SortedKeys(schema.Properties) {
+			props[name] = rewriteSchema(pointer+"/properties/"+gen.EscapeToken(name), schema.Properties[name], fn)
+		}
+		schema.Properties = props
+	}
+
+	if schema.Items != nil && schema.Items.Schema != nil {
+		items := rewriteSchema(pointer+"/items", *schema.Items.Schema, fn)
+		schema.Items = &v3.Items{Schema: &items}
+	}
+
+	if schema.AdditionalProperties != nil && schema.AdditionalProperties.Schema != nil {
+		value := rewriteSchema(pointer+"/additionalProperties", *schema.AdditionalProperties.Schema, fn)
+		schema.AdditionalProperties = &v3.AdditionalProperties{Allowed: schema.AdditionalProperties.Allowed, Schema: &value}
+	}
+
+	schema.OneOf = rewriteSchemaList(pointer+"/oneOf", schema.OneOf, fn)
+	schema.AnyOf = rewriteSchemaList(pointer+"/anyOf", schema.AnyOf, fn)
+	schema.AllOf = rewriteSchemaList(pointer+"/allOf", schema.AllOf, fn)
+	return schema
+}
+
+// rewriteSchemaList rewrites each schema of a composition into a new list.
+func rewriteSchemaList(pointer string, list []v3.Schema, fn func(pointer string, schema v3.Schema) v3.Schema) []v3.Schema {
+	if len(list) == 0 {
+		return list
+	}
+
+	res := make([]v3.Schema, len(list))
+	for i, schema := range list {
+		res[i] = rewriteSchema(pointer+"/"+strconv.Itoa(i), schema, fn)
+	}
+	return res
+}
@@ -0,0 +1,111 @@
+// Copyright 2020 Torben Schinke
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package async
+
+import (
+	"github.com/golangee/openapi-client/internal/gen"
+	v3 "github.com/golangee/openapi/v3"
+	"strings"
+)
+
+// isOptionalPointer checks if the property is rendered as pointer because it is optional or nullable.
+func isOptionalPointer(opts Options, owner v3.Schema, property string) bool {
+	return opts.OptionalPointers && (!isRequired(owner, property) || owner.Properties[property].Nullable)
+}
+
+// isRecursiveField checks if the property of the named struct refers to a struct by value, which in turn contains
+// the named struct again by value, like a Category with a parent Category. Such a field requires a pointer,
+// otherwise the Go type would have an infinite size. Slices, maps and pointers already break a cycle.
+func isRecursiveField(opts Options, f *gen.GoGenFile, doc *v3.Document, name string, owner v3.Schema, property string) bool {
+	if isOptionalPointer(opts, owner, property) {
+		return false
+	}
+
+	target, ok := structComponentName(doc, owner.Properties[property])
+	if !ok {
+		return false
+	}
+
+	return reachesByValue(opts, f, doc, target, name, map[string]bool{})
+}
+
+// reachesByValue checks if the struct component from contains the struct component to by value, either directly
+// or transitively.
+func reachesByValue(opts Options, f *gen.GoGenFile, doc *v3.Document, from, to string, visited map[string]bool) bool {
+	if from == to {
+		return true
+	}
+
+	if visited[from] {
+		return false
+	}
+	visited[from] = true
+
+	schema, ok := structSchema(opts, f, doc, from)
+	if !ok {
+		return false
+	}
+
+	for _, property := range gen.SortedKeys(schema.Properties) {
+		if isOptionalPointer(opts, schema, property) {
+			continue
+		}
+
+		target, ok := structComponentName(doc, schema.Properties[property])
+		if ok && reachesByValue(opts, f, doc, target, to, visited) {
+			return true
+		}
+	}
+	return false
+}
+
+// structComponentName returns the name of the component, which is referenced by the schema, after following
+// referencing components. Returns false, if the schema is no reference.
+func structComponentName(doc *v3.Document, schema v3.Schema) (string, bool) {
+	visited := map[string]bool{}
+	for schema.Ref != nil && strings.HasPrefix(*schema.Ref, gen.ComponentSchemaPrefix) {
+		name, target := doc.ResolveRef(*schema.Ref)
+		if target == nil || visited[name] {
+			return "", false
+		}
+
+		visited[name] = true
+		if target.Ref == nil {
+			return name, true
+		}
+		schema = *target
+	}
+	return "", false
+}
+
+// structSchema returns the schema of the named component, as it is emitted as struct, or false, if the component
+// is not a struct at all.
+func structSchema(opts Options, f *gen.GoGenFile, doc *v3.Document, name string) (v3.Schema, bool) {
+	schema, has := doc.Components.Schemas[name]
+	if !has {
+		return schema, false
+	}
+
+	if _, ok := findTypeMapping(opts, componentPointer(name), schema); ok || isEnum(schema) || isUnion(schema) {
+		return schema, false
+	}
+
+	if len(schema.AllOf) > 0 {
+		merged, err := mergeAllOf(opts, f, doc, name, schema)
+		return merged, err == nil
+	}
+
+	return schema, isObject(schema) && len(schema.Properties) > 0
+}
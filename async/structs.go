@@ -18,6 +18,7 @@ import (
 	"fmt"
 	"github.com/golangee/openapi-client/internal/gen"
 	v3 "github.com/golangee/openapi/v3"
+	"strconv"
 	"strings"
 )
 
@@ -27,87 +28,246 @@ func emitTypes(opts Options, f *gen.GoGenFile, doc *v3.Document) error {
 	}
 
 	for _, name := range gen.SortedKeys(doc.Components.Schemas) {
-		if name == "Error" {
+		if name == builtinErrorModelName(opts) {
 			continue // we ignore our own build-in type
 		}
 		schema := doc.Components.Schemas[name]
 		err := emitType(opts, f, doc, name, schema)
 		if err != nil {
-			return err
+			return diagnosticAt(componentPointer(name), err)
 		}
 	}
 	return nil
 }
 
 func emitType(opts Options, f *gen.GoGenFile, doc *v3.Document, name string, schema v3.Schema) error {
-	for _, v := range opts.UseReferences {
-		if schema.XType != nil && *schema.XType == v {
-			return nil
+	if _, ok := findTypeMapping(opts, componentPointer(name), schema); ok {
+		return nil
+	}
+
+	if isEnum(schema) {
+		return emitEnum(opts, f, doc, name, schema)
+	}
+
+	if isUnion(schema) {
+		return emitUnion(opts, f, doc, name, schema)
+	}
+
+	if len(schema.AllOf) > 0 {
+		merged, err := mergeAllOf(opts, f, doc, name, schema)
+		if err != nil {
+			return err
 		}
+		return emitStruct(opts, f, doc, name, merged)
 	}
 
-	switch schema.Type {
-	case v3.String:
-		fallthrough
-	case v3.Number:
-		fallthrough
-	case v3.Integer:
-		fallthrough
-	case v3.Boolean:
-		fallthrough
-	case v3.Array:
-		panic(schema)
-	case v3.Object:
+	if isObject(schema) {
+		mapType, ok, err := mapTypeName(opts, f, doc, schema)
+		if err != nil {
+			return err
+		}
+
+		if ok {
+			f.Printf(gen.Comment(schema.Description))
+			f.Printf("type %s %s\n\n", name, mapType)
+			return nil
+		}
 		return emitStruct(opts, f, doc, name, schema)
+	}
+
+	switch {
+	case schema.Type == v3.String || schema.Type == v3.Number || schema.Type == v3.Integer || isBoolean(schema) ||
+		schema.Type == v3.Array || schema.Ref != nil || isAnySchema(schema):
+		return emitNamedType(opts, f, doc, name, schema)
+	case schema.Type == "":
+		return newDiagnostic("", "schema without type is not supported")
 	default:
-		panic(schema.Type)
+		return newDiagnostic("", "unsupported type '%s'", schema.Type)
+	}
+}
+
+// emitNamedType declares a named type for a primitive, array or referencing component, like type Id string or
+// type Tags []Tag. Types which are not builtin, like time.Time or other components, are declared as alias, so
+// that they keep their methods and json representation.
+func emitNamedType(opts Options, f *gen.GoGenFile, doc *v3.Document, name string, schema v3.Schema) error {
+	tname, err := typeName(opts, f, doc, schema)
+	if err != nil {
+		return err
+	}
+
+	f.Printf(gen.Comment(schema.Description))
+	if isBuiltinType(tname) {
+		f.Printf("type %s %s\n\n", name, tname)
+	} else {
+		f.Printf("type %s = %s\n\n", name, tname)
 	}
 	return nil
 }
 
+// isBuiltinType checks if the type is a predeclared type or a slice or map, so that a type declaration based on
+// it has the same json representation.
+func isBuiltinType(tname string) bool {
+	if strings.HasPrefix(tname, "[]") || strings.HasPrefix(tname, "map[") {
+		return true
+	}
+
+	switch tname {
+	case "string", "bool", "int", "int32", "int64", "float32", "float64":
+		return true
+	default:
+		return false
+	}
+}
+
+// isBoolean checks for the boolean type. The model declares v3.Boolean as bool, but specifications use boolean.
+func isBoolean(schema v3.Schema) bool {
+	return schema.Type == v3.Boolean || schema.Type == "boolean"
+}
+
 func emitStruct(opts Options, f *gen.GoGenFile, doc *v3.Document, name string, schema v3.Schema) error {
 	f.Printf(gen.Comment(schema.Description))
 	f.Printf("type %s struct{\n", name)
 	f.ShiftRight()
+	usedNames := map[string]bool{}
 	for _, fieldName := range gen.SortedKeys(schema.Properties) {
 		field := schema.Properties[fieldName]
 		f.Printf(gen.Comment(field.Description))
-		f.Printf("%s %s\n", gen.Public(fieldName), typeName(opts, f, doc, field))
+		required := isRequired(schema, fieldName)
+		tname, err := typeName(opts, f, doc, field)
+		if err != nil {
+			return diagnosticAt("/properties/"+gen.EscapeToken(fieldName), err)
+		}
+
+		if isOptionalPointer(opts, schema, fieldName) || isRecursiveField(opts, f, doc, name, schema, fieldName) {
+			tname = pointerTypeName(tname)
+		}
+		f.Printf("%s %s %s\n", uniqueName(usedNames, gen.Identifier(fieldName)), tname, jsonTag(fieldName, required))
+	}
+
+	valueType, hasAdditional, err := additionalPropertiesTypeName(opts, f, doc, schema)
+	if err != nil {
+		return err
+	}
+
+	additionalField := uniqueName(usedNames, "AdditionalProperties")
+	if hasAdditional {
+		f.Printf("// %s contains all undeclared properties.\n", additionalField)
+		f.Printf("%s map[string]%s `json:\"-\"`\n", additionalField, valueType)
 	}
 	f.ShiftLeft()
 	f.Printf("}\n\n")
+
+	if hasAdditional {
+		emitAdditionalPropertiesJSON(f, name, additionalField, valueType, gen.SortedKeys(schema.Properties))
+	}
 	return nil
 }
 
-func typeName(opts Options, f *gen.GoGenFile, doc *v3.Document, schema v3.Schema) string {
+// isRequired checks if the property is listed as required in the object schema.
+func isRequired(schema v3.Schema, property string) bool {
+	for _, name := range schema.Required {
+		if name == property {
+			return true
+		}
+	}
+	return false
+}
+
+// pointerTypeName returns a pointer to the given type, if the type cannot already represent an absent value
+// by itself, like slices, maps, pointers and interfaces.
+func pointerTypeName(tname string) string {
+	if strings.HasPrefix(tname, "[]") || strings.HasPrefix(tname, "map[") || strings.HasPrefix(tname, "*") ||
+		tname == "interface{}" {
+		return tname
+	}
+	return "*" + tname
+}
+
+// jsonTag returns the struct tag which maps the field to its exact property name. Optional properties are omitted
+// if empty.
+func jsonTag(property string, required bool) string {
+	if required {
+		return fmt.Sprintf("`json:\"%s\"`", property)
+	}
+	return fmt.Sprintf("`json:\"%s,omitempty\"`", property)
+}
+
+// uniqueName returns name or, if it has already been used, name with a numbered suffix.
+func uniqueName(usedNames map[string]bool, name string) string {
+	tmp := name
+	for i := 2; usedNames[tmp]; i++ {
+		tmp = name + strconv.Itoa(i)
+	}
+	usedNames[tmp] = true
+	return tmp
+}
+
+// typeName resolves the Go type of the schema. An unsupported schema results in a *Diagnostic, whose pointer is
+// relative to the schema.
+func typeName(opts Options, f *gen.GoGenFile, doc *v3.Document, schema v3.Schema) (string, error) {
+	if m, ok := findTypeMapping(opts, "", schema); ok {
+		return mappedTypeName(f, m), nil
+	}
+
+	if tname, ok := formatTypeName(opts, f, schema); ok {
+		return tname, nil
+	}
+
+	if isBoolean(schema) {
+		return "bool", nil
+	}
+
 	switch schema.Type {
 	case v3.String:
-		return "string"
+		return "string", nil
 	case v3.Number:
-		return "float64"
+		return "float64", nil
 	case v3.Integer:
-		return "int"
-	case v3.Boolean:
-		return "bool"
+		return "int", nil
 	case v3.Array:
-		return "[]" + typeName(opts, f, doc, *schema.Items.Schema)
-	case v3.Object:
-		return *schema.Ref
-	default:
-		if schema.Ref != nil {
-			name, schema := doc.ResolveRef(*schema.Ref)
-			if schema != nil {
-
-				for _, v := range opts.UseReferences {
-					if schema.XType != nil && *schema.XType == v {
-						tuple := strings.Split(*schema.XType, "#")
-						return f.ImportName(tuple[0], tuple[1])
-					}
-				}
-
-				return name
-			}
+		if schema.Items == nil || schema.Items.Schema == nil {
+			return "", newDiagnostic("", "array without items")
+		}
+
+		tname, err := typeName(opts, f, doc, *schema.Items.Schema)
+		if err != nil {
+			return "", diagnosticAt("/items", err)
 		}
-		panic(fmt.Sprintf("%+v", schema))
+		return "[]" + tname, nil
 	}
+
+	if schema.Ref != nil {
+		name, target := doc.ResolveRef(*schema.Ref)
+		if target == nil {
+			return "", newDiagnostic("", "unresolvable reference '%s'", *schema.Ref)
+		}
+
+		if m, ok := findTypeMapping(opts, componentPointer(name), *target); ok {
+			return mappedTypeName(f, m), nil
+		}
+		return name, nil
+	}
+
+	if isObject(schema) {
+		mapType, ok, err := mapTypeName(opts, f, doc, schema)
+		if err != nil {
+			return "", err
+		}
+
+		if ok {
+			return mapType, nil
+		}
+		// inline objects are hoisted and replaced by a reference, but not in every location
+		return "", newDiagnostic("", "inline object is not supported here")
+	}
+
+	if isAnySchema(schema) {
+		return f.ImportName("encoding/json", "RawMessage"), nil
+	}
+
+	if schema.Type == "" {
+		return "", newDiagnostic("", "schema without type is not supported here")
+	}
+
+	return "", newDiagnostic("", "unsupported type '%s'", schema.Type)
 }
@@ -0,0 +1,193 @@
+// Copyright 2020 Torben Schinke
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package async
+
+import (
+	"github.com/golangee/openapi-client/internal/gen"
+	v3 "github.com/golangee/openapi/v3"
+	"strconv"
+	"strings"
+)
+
+// unionVariant is a single alternative of a oneOf or anyOf schema.
+type unionVariant struct {
+	name           string   // name of the variant, e.g. Cat for NewPetFromCat and AsCat
+	typeName       string   // typeName of the go type, e.g. Cat
+	discriminators []string // discriminators contains the property values which select this variant
+}
+
+// isUnion checks if the schema is a oneOf or anyOf composition.
+func isUnion(schema v3.Schema) bool {
+	return len(schema.OneOf) > 0 || len(schema.AnyOf) > 0
+}
+
+// unionVariants returns the variants of the oneOf or anyOf schema in declaration order.
+func unionVariants(opts Options, f *gen.GoGenFile, doc *v3.Document, schema v3.Schema) ([]unionVariant, error) {
+	schemas, kind := schema.OneOf, "oneOf"
+	if len(schemas) == 0 {
+		schemas, kind = schema.AnyOf, "anyOf"
+	}
+
+	usedNames := map[string]bool{"Value": true, "MarshalJSON": true, "UnmarshalJSON": true}
+	var res []unionVariant
+	for i, variantSchema := range schemas {
+		tname, err := typeName(opts, f, doc, variantSchema)
+		if err != nil {
+			return nil, diagnosticAt("/"+kind+"/"+strconv.Itoa(i), err)
+		}
+
+		variant := unionVariant{typeName: tname, name: uniqueName(usedNames, variantName(tname))}
+		if variantSchema.Ref != nil {
+			refName := strings.TrimPrefix(*variantSchema.Ref, gen.ComponentSchemaPrefix)
+			variant.discriminators = discriminatorValues(schema.Discriminator, refName)
+		}
+		res = append(res, variant)
+	}
+	return res, nil
+}
+
+// variantName derives a variant name from a go type, e.g. StringList from []string. Accessors are prefixed with
+// As, so that a variant like String does not clash with fmt.Stringer.
+func variantName(tname string) string {
+	if strings.HasPrefix(tname, "[]") {
+		return variantName(tname[2:]) + "List"
+	}
+	return gen.Identifier(tname)
+}
+
+// discriminatorValues returns the explicitly mapped values of the referenced component or, if not mapped at all,
+// the component name itself.
+func discriminatorValues(discriminator *v3.Discriminator, refName string) []string {
+	if discriminator == nil {
+		return nil
+	}
+
+	var res []string
+	for _, value := range gen.SortedKeys(discriminator.Mapping) {
+		target := discriminator.Mapping[value]
+		if target == refName || strings.TrimPrefix(target, gen.ComponentSchemaPrefix) == refName {
+			res = append(res, value)
+		}
+	}
+
+	if len(res) == 0 {
+		res = append(res, refName)
+	}
+	return res
+}
+
+// emitUnion generates a tagged union wrapper with a constructor and an accessor for each variant. The json
+// representation is the variant itself. When decoding, the discriminator property selects the variant. Without
+// a discriminator, the first variant which decodes without unknown fields is picked.
+func emitUnion(opts Options, f *gen.GoGenFile, doc *v3.Document, name string, schema v3.Schema) error {
+	variants, err := unionVariants(opts, f, doc, schema)
+	if err != nil {
+		return err
+	}
+
+	var names []string
+	for _, variant := range variants {
+		names = append(names, variant.typeName)
+	}
+
+	if schema.Description != "" {
+		f.Printf(gen.Comment(schema.Description))
+	} else {
+		f.Printf("// %s is one of %s.\n", name, strings.Join(names, ", "))
+	}
+	f.Printf("type %s struct {\n", name)
+	f.Printf("value interface{}\n")
+	f.Printf("}\n\n")
+
+	for _, variant := range variants {
+		f.Printf("// New%sFrom%s creates a %s holding the given %s.\n", name, variant.name, name, variant.typeName)
+		f.Printf("func New%sFrom%s(v %s) %s {\n", name, variant.name, variant.typeName, name)
+		f.Printf("return %s{value: v}\n", name)
+		f.Printf("}\n\n")
+
+		f.Printf("// As%s returns the value and true, if it holds a %s.\n", variant.name, variant.typeName)
+		f.Printf("func (u %s) As%s() (%s, bool) {\n", name, variant.name, variant.typeName)
+		f.Printf("v, ok := u.value.(%s)\n", variant.typeName)
+		f.Printf("return v, ok\n")
+		f.Printf("}\n\n")
+	}
+
+	f.Printf("// Value returns the actual variant or nil.\n")
+	f.Printf("func (u %s) Value() interface{} {\n", name)
+	f.Printf("return u.value\n")
+	f.Printf("}\n\n")
+
+	f.Printf("// MarshalJSON encodes the actual variant.\n")
+	f.Printf("func (u %s) MarshalJSON() ([]byte, error) {\n", name)
+	f.Printf("return %s(u.value)\n", f.ImportName("encoding/json", "Marshal"))
+	f.Printf("}\n\n")
+
+	f.Printf("// UnmarshalJSON decodes the matching variant.\n")
+	f.Printf("func (u *%s) UnmarshalJSON(buf []byte) error {\n", name)
+	if schema.Discriminator != nil {
+		emitDiscriminatedUnmarshal(f, name, schema.Discriminator.PropertyName, variants)
+	} else {
+		emitProbingUnmarshal(f, name, variants)
+	}
+	f.Printf("}\n\n")
+	return nil
+}
+
+// emitDiscriminatedUnmarshal reads the discriminator property and decodes the variant it selects.
+func emitDiscriminatedUnmarshal(f *gen.GoGenFile, name, property string, variants []unionVariant) {
+	f.Printf("var probe struct {\n")
+	f.Printf("Discriminator string `json:%q`\n", property)
+	f.Printf("}\n")
+	f.Printf("if err := %s(buf, &probe); err != nil {\n", f.ImportName("encoding/json", "Unmarshal"))
+	f.Printf("return err\n")
+	f.Printf("}\n\n")
+	f.Printf("switch probe.Discriminator {\n")
+	for _, variant := range variants {
+		if len(variant.discriminators) == 0 {
+			continue
+		}
+
+		var literals []string
+		for _, value := range variant.discriminators {
+			literals = append(literals, strconv.Quote(value))
+		}
+		f.Printf("case %s:\n", strings.Join(literals, ","))
+		f.Printf("var v %s\n", variant.typeName)
+		f.Printf("if err := %s(buf, &v); err != nil {\n", f.ImportName("encoding/json", "Unmarshal"))
+		f.Printf("return err\n")
+		f.Printf("}\n")
+		f.Printf("u.value = v\n")
+		f.Printf("return nil\n")
+	}
+	f.Printf("}\n\n")
+	f.Printf("return %s(\"unknown %s %s: %%s\", probe.Discriminator)\n", f.ImportName("fmt", "Errorf"), name, property)
+}
+
+// emitProbingUnmarshal tries each variant in declaration order and picks the first, which decodes without
+// unknown fields.
+func emitProbingUnmarshal(f *gen.GoGenFile, name string, variants []unionVariant) {
+	for _, variant := range variants {
+		f.Printf("{\n")
+		f.Printf("var v %s\n", variant.typeName)
+		f.Printf("dec := %s(%s(buf))\n", f.ImportName("encoding/json", "NewDecoder"), f.ImportName("bytes", "NewReader"))
+		f.Printf("dec.DisallowUnknownFields()\n")
+		f.Printf("if err := dec.Decode(&v); err == nil {\n")
+		f.Printf("u.value = v\n")
+		f.Printf("return nil\n")
+		f.Printf("}\n")
+		f.Printf("}\n\n")
+	}
+	f.Printf("return %s(\"no variant of %s matches\")\n", f.ImportName("fmt", "Errorf"), name)
+}
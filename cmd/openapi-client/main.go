@@ -0,0 +1,147 @@
+// Copyright 2020 Torben Schinke
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command openapi-client generates a client from an OpenAPI specification in JSON or YAML format. It is intended to be invoked by
+// go generate, e.g.
+//
+//	//go:generate go run github.com/golangee/openapi-client/cmd/openapi-client -spec api.json
+//
+// The output directory defaults to the working directory and the package name to $GOPACKAGE, which are both
+// provided by go generate.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/golangee/openapi-client/async"
+	"os"
+	"strings"
+)
+
+const asyncMode = "async"
+
+// stringList collects the values of a repeatable flag.
+type stringList []string
+
+func (s *stringList) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringList) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string) error {
+	fs := flag.NewFlagSet("openapi-client", flag.ContinueOnError)
+	specFile := fs.String("spec", "", "path of the OpenAPI specification file in JSON or YAML format (required)")
+	out := fs.String("out", ".", "directory of the generated file, absolute or relative to the working directory, or - for stdout")
+	pkg := fs.String("pkg", os.Getenv("GOPACKAGE"), "package name of the generated file, defaults to $GOPACKAGE")
+	mode := fs.String("mode", asyncMode, "generator mode, currently only "+asyncMode)
+	errorModel := fs.String("error-model", "", "golangee, problem or a type reference like github.com/myproject/errors#MyError")
+	optionalPointers := fs.Bool("optional-pointers", false, "render optional and nullable properties as pointers")
+	lenientEnums := fs.Bool("lenient-enums", false, "accept unknown enum values when decoding")
+	skipUnsupported := fs.Bool("skip-unsupported", false, "leave out unsupported schemas and operations instead of failing")
+
+	var refs, mappings, formats stringList
+	fs.Var(&refs, "ref", "x-ee.type reference like github.com/golangee/uuid#UUID to use instead of a generated type (repeatable)")
+	fs.Var(&mappings, "map", "component name or JSON pointer mapped to a Go type, like User=github.com/myproject/domain#User (repeatable)")
+	fs.Var(&formats, "format", "schema format mapped to a Go type, like uuid=github.com/google/uuid#UUID (repeatable)")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *specFile == "" {
+		return fmt.Errorf("the -spec flag is required")
+	}
+
+	if *pkg == "" {
+		return fmt.Errorf("the -pkg flag is required outside of go generate")
+	}
+
+	if *mode != asyncMode {
+		return fmt.Errorf("unsupported mode '%s'", *mode)
+	}
+
+	opts := async.Options{
+		TargetDir:        *out,
+		TargetPackage:    *pkg,
+		UseReferences:    refs,
+		OptionalPointers: *optionalPointers,
+		ErrorModel:       *errorModel,
+		LenientEnums:     *lenientEnums,
+		SkipUnsupported:  *skipUnsupported,
+		OnSkip: func(d *async.Diagnostic) {
+			fmt.Fprintf(os.Stderr, "skipped %s\n", d)
+		},
+	}
+
+	if *out == "-" {
+		opts.TargetDir = ""
+		opts.Output = os.Stdout
+	}
+
+	for _, m := range mappings {
+		mapping, err := parseTypeMapping(m)
+		if err != nil {
+			return err
+		}
+		opts.TypeMappings = append(opts.TypeMappings, mapping)
+	}
+
+	if len(formats) > 0 {
+		opts.Formats = map[string]string{}
+		for _, f := range formats {
+			format, goType, err := splitAssignment("format", f)
+			if err != nil {
+				return err
+			}
+			opts.Formats[format] = goType
+		}
+	}
+
+	return async.GenerateFile(*specFile, opts)
+}
+
+// parseTypeMapping parses a mapping like User=github.com/myproject/domain#User. A key starting with # is
+// a JSON pointer, otherwise a component name.
+func parseTypeMapping(value string) (async.TypeMapping, error) {
+	key, goType, err := splitAssignment("map", value)
+	if err != nil {
+		return async.TypeMapping{}, err
+	}
+
+	if strings.HasPrefix(key, "#") {
+		return async.TypeMapping{Pointer: key, GoType: goType}, nil
+	}
+	return async.TypeMapping{Component: key, GoType: goType}, nil
+}
+
+// splitAssignment splits a flag value like key=value at the last equal sign, because JSON pointers may contain
+// equal signs as well.
+func splitAssignment(flagName, value string) (string, string, error) {
+	idx := strings.LastIndex(value, "=")
+	if idx <= 0 || idx == len(value)-1 {
+		return "", "", fmt.Errorf("invalid -%s value '%s': expected key=value", flagName, value)
+	}
+	return value[:idx], value[idx+1:], nil
+}
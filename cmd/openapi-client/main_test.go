@@ -0,0 +1,192 @@
+// Copyright 2020 Torben Schinke
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"github.com/golangee/openapi-client/async"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseTypeMapping(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected async.TypeMapping
+		err      string
+	}{
+		{
+			value:    "User=github.com/myproject/domain#User",
+			expected: async.TypeMapping{Component: "User", GoType: "github.com/myproject/domain#User"},
+		},
+		{
+			value:    "#/components/schemas/User/properties/id=int64",
+			expected: async.TypeMapping{Pointer: "#/components/schemas/User/properties/id", GoType: "int64"},
+		},
+		{
+			value:    "#/components/schemas/a=b/properties/id=string",
+			expected: async.TypeMapping{Pointer: "#/components/schemas/a=b/properties/id", GoType: "string"},
+		},
+		{value: "User", err: "invalid -map value 'User': expected key=value"},
+		{value: "=int64", err: "invalid -map value '=int64': expected key=value"},
+		{value: "User=", err: "invalid -map value 'User=': expected key=value"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			mapping, err := parseTypeMapping(tt.value)
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					t.Fatalf("expected error %s but got %v", tt.err, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if mapping != tt.expected {
+				t.Fatalf("expected %+v but got %+v", tt.expected, mapping)
+			}
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	// the output must be within the module
+	dir, err := ioutil.TempDir(".", "openapi-client")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	specFile := filepath.Join(dir, "api.json")
+	if err := ioutil.WriteFile(specFile, []byte(spec), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	err = run([]string{
+		"-spec", specFile,
+		"-out", dir,
+		"-pkg", "client",
+		"-ref", "github.com/golangee/uuid#UUID",
+		"-map", "Money=github.com/myproject/domain#Money",
+		"-map", "#/components/schemas/Order/properties/count=int64",
+		"-format", "date-time=string",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf, err := ioutil.ReadFile(filepath.Join(dir, "openapiclient.gen.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	src := string(buf)
+	for _, expected := range []string{
+		"package client",
+		"Id uuid.UUID `json:\"id\"`",
+		"Total domain.Money `json:\"total\"`",
+		"Count int64 `json:\"count\"`",
+		"CreatedAt string `json:\"createdAt\"`",
+	} {
+		if !strings.Contains(src, expected) {
+			t.Fatalf("expected %s in\n%s", expected, src)
+		}
+	}
+
+	if strings.Contains(src, "type Money struct") {
+		t.Fatalf("expected the mapped component to be left out in\n%s", src)
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "openapi-client")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	specFile := filepath.Join(dir, "api.json")
+	if err := ioutil.WriteFile(specFile, []byte(spec), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		args []string
+		err  string
+	}{
+		{args: []string{"-pkg", "client"}, err: "the -spec flag is required"},
+		{args: []string{"-spec", specFile, "-pkg", ""}, err: "the -pkg flag is required outside of go generate"},
+		{args: []string{"-spec", specFile, "-pkg", "client", "-mode", "sync"}, err: "unsupported mode 'sync'"},
+		{args: []string{"-spec", specFile, "-pkg", "client", "-map", "Money"}, err: "invalid -map value 'Money': expected key=value"},
+		{args: []string{"-spec", specFile, "-pkg", "client", "-format", "uuid"}, err: "invalid -format value 'uuid': expected key=value"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.err, func(t *testing.T) {
+			err := run(tt.args)
+			if err == nil || err.Error() != tt.err {
+				t.Fatalf("expected error %s but got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+const spec = `{
+   "openapi":"3.0.1",
+   "info":{
+      "title":"test",
+      "version":"1.0"
+   },
+   "paths":{},
+   "components":{
+      "schemas":{
+         "Money":{
+            "type":"object",
+            "properties":{
+               "amount":{
+                  "type":"integer"
+               }
+            }
+         },
+         "Order":{
+            "type":"object",
+            "required":["id", "total", "count", "createdAt"],
+            "properties":{
+               "id":{
+                  "type":"string",
+                  "x-ee.type":"github.com/golangee/uuid#UUID"
+               },
+               "total":{
+                  "$ref":"#/components/schemas/Money"
+               },
+               "count":{
+                  "type":"integer"
+               },
+               "createdAt":{
+                  "type":"string",
+                  "format":"date-time"
+               }
+            }
+         }
+      }
+   }
+}
+`
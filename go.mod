@@ -2,6 +2,9 @@ module github.com/golangee/openapi-client
 
 go 1.14
 
-require github.com/golangee/openapi v0.0.0-20200525170920-8f88c5ab15c9
+require (
+	github.com/golangee/openapi v0.0.0-20200525170920-8f88c5ab15c9
+	gopkg.in/yaml.v3 v3.0.1
+)
 
 replace github.com/golangee/openapi => ../openapi
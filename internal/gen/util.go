@@ -15,34 +15,21 @@
 package gen
 
 import (
-	"fmt"
-	"os"
-	"path/filepath"
 	"reflect"
 	"sort"
 	"strings"
 	"unicode"
 )
 
-// ModRootDir returns the root directory of current module. If the current working directory is not a module
-// returns an error.
-func ModRootDir() (string, error) {
-	cwd, err := os.Getwd()
-	if err != nil {
-		return "", err
-	}
-	root := cwd
-	for {
-		stat, err := os.Stat(filepath.Join(root, "go.mod"))
-		if err == nil && stat.Mode().IsRegular() {
-			return root, nil
-		}
-		root = filepath.Dir(root)
-		if root == "/" || root == "." {
-			return "", fmt.Errorf("%s is not withing a go module", cwd)
-		}
-	}
-}
+// ComponentSchemaPrefix is the JSON pointer of the component schemas, followed by the escaped name of a schema.
+const ComponentSchemaPrefix = "#/components/schemas/"
+
+const (
+	// FormMediaType is the media type of url encoded form bodies.
+	FormMediaType = "application/x-www-form-urlencoded"
+	// MultipartMediaType is the media type of multipart form bodies, which may contain files.
+	MultipartMediaType = "multipart/form-data"
+)
 
 // Public ensures that str starts with an uppercase letter
 func Public(str string) string {
@@ -52,6 +39,41 @@ func Public(str string) string {
 	return string(unicode.ToUpper(rune(str[0]))) + str[1:]
 }
 
+// Private ensures that str starts with a lowercase letter
+func Private(str string) string {
+	if str == "" {
+		return str
+	}
+	return string(unicode.ToLower(rune(str[0]))) + str[1:]
+}
+
+// Identifier converts an arbitrary name like user_id, x-count or @type into an exported Go identifier. Each
+// character which is not allowed in an identifier is dropped and the following letter is upper cased. A name which
+// would start with a digit is prefixed with an X.
+func Identifier(str string) string {
+	sb := &strings.Builder{}
+	nextUp := true
+	for _, r := range str {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			nextUp = true
+			continue
+		}
+		if sb.Len() == 0 && unicode.IsDigit(r) {
+			sb.WriteRune('X')
+		}
+		if nextUp {
+			sb.WriteRune(unicode.ToUpper(r))
+			nextUp = false
+		} else {
+			sb.WriteRune(r)
+		}
+	}
+	if sb.Len() == 0 {
+		return "X"
+	}
+	return sb.String()
+}
+
 // Comment assembles a string with correct newlines and // at the beginning of each line
 func Comment(str string) string {
 	str = strings.TrimSpace(str)
@@ -94,3 +116,13 @@ func SlashToCamelCase(str string) string {
 	}
 	return sb.String()
 }
+
+// EscapeToken escapes a single reference token of a JSON pointer as defined by RFC 6901.
+func EscapeToken(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
+
+// UnescapeToken reverses EscapeToken.
+func UnescapeToken(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+}
@@ -15,8 +15,10 @@
 package gen
 
 import (
+	"errors"
 	"fmt"
 	"go/format"
+	"go/scanner"
 	"strconv"
 	"strings"
 )
@@ -122,13 +124,20 @@ func (w *GoGenFile) String() string {
 	return tmp.String()
 }
 
-func (w *GoGenFile) FormatString() string {
-	b, err := format.Source([]byte(w.String()))
+// FormatString returns the gofmt formatted source. If the generated source is invalid, the error contains the
+// numbered lines around the first syntax error.
+func (w *GoGenFile) FormatString() (string, error) {
+	src := w.String()
+	b, err := format.Source([]byte(src))
 	if err != nil {
-		fmt.Println(enumerateText(w.String()))
-		panic(err)
+		var list scanner.ErrorList
+		if errors.As(err, &list) && len(list) > 0 {
+			line := list[0].Pos.Line
+			return "", fmt.Errorf("generated source is invalid: %w\n%s", err, enumerateText(src, line-3, line+3))
+		}
+		return "", fmt.Errorf("generated source is invalid: %w", err)
 	}
-	return string(b)
+	return string(b), nil
 }
 
 func lastName(text string) string {
@@ -140,10 +149,14 @@ func lastName(text string) string {
 	return pkgname
 }
 
-func enumerateText(text string) string {
+// enumerateText returns the lines from and to (inclusive, starting at 1) prefixed with their line number.
+func enumerateText(text string, from, to int) string {
 	sb := &strings.Builder{}
 	lines := strings.Split(text, "\n")
 	for i, line := range lines {
+		if i+1 < from || i+1 > to {
+			continue
+		}
 		sb.WriteString(strconv.Itoa(i+1) + ": " + line + "\n")
 	}
 	return sb.String()
@@ -0,0 +1,520 @@
+// Copyright 2020 Torben Schinke
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package load
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/golangee/openapi-client/internal/gen"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// kind tells the bundler how to interpret the values of a document node.
+type kind int
+
+const (
+	// object is any node of the specification, which is no schema, like a path item or a parameter.
+	object kind = iota
+	// schema is a node which contains a schema.
+	schema
+	// schemaMap contains schemas as values, like properties.
+	schemaMap
+	// schemaList contains schemas as elements, like allOf.
+	schemaList
+	// opaque contains arbitrary values, like examples, which must not be interpreted.
+	opaque
+)
+
+// bundler collects the schemas of other files into the components of the root document.
+type bundler struct {
+	// root is the absolute path of the root document.
+	root string
+	// docs contains the decoded documents by their absolute path.
+	docs map[string]interface{}
+	// names contains the component name for each bundled reference target, like /abs/user.yaml#/User.
+	names map[string]string
+	// taken contains all component names in use in lower case, because they become Go identifiers.
+	taken map[string]bool
+	// bundled contains the schemas of other files by their component name.
+	bundled map[string]interface{}
+	// inlining contains the reference targets, which are currently inlined, to detect inlining cycles.
+	inlining map[string]bool
+	// swagger is true, if the root document has been converted from Swagger 2.0.
+	swagger bool
+}
+
+// BundleFile reads the document from the local file system like Bundle.
+func BundleFile(fname string) ([]byte, error) {
+	buf, err := ioutil.ReadFile(fname)
+	if err != nil {
+		return nil, err
+	}
+
+	return Bundle(fname, buf)
+}
+
+// Bundle returns the document as JSON, like ToJSON, but resolves all references into other files of the local
+// file system, like ./schemas/user.yaml#/User. Relative paths are resolved against the directory of the
+// referring file, which is the working directory for a root document without a name. Referenced schemas
+// become component schemas of the returned document, whose names are derived from the last token of the
+// reference and made unique by a number, like User2. Any other referenced object, like a parameter, is inlined,
+// even if it is declared by the root document itself.
+// Remote references and reference cycles, which never lead to an actual schema, are rejected. A Swagger 2.0
+// document is converted into an OpenAPI 3 document first.
+func Bundle(name string, buf []byte) ([]byte, error) {
+	tree, err := decode(name, buf)
+	if err != nil {
+		return nil, err
+	}
+
+	swagger := isSwagger(tree)
+	if swagger {
+		tree, err = convertSwagger(tree)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	root, err := filepath.Abs(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if name == "" {
+		root = filepath.Join(root, "openapi")
+	}
+
+	b := &bundler{
+		root:     root,
+		docs:     map[string]interface{}{root: tree},
+		names:    map[string]string{},
+		taken:    map[string]bool{},
+		bundled:  map[string]interface{}{},
+		inlining: map[string]bool{},
+		swagger:  swagger,
+	}
+
+	res, err := b.bundle(tree)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(res)
+}
+
+// bundle rewrites the root document. Root components, which only refer to a schema of another file, take over
+// that schema under their own name.
+func (b *bundler) bundle(tree interface{}) (interface{}, error) {
+	schemas := rootSchemas(tree)
+	for _, name := range gen.SortedKeys(schemas) {
+		b.taken[strings.ToLower(name)] = true
+	}
+
+	var adopted []string
+	for _, name := range gen.SortedKeys(schemas) {
+		ref, ok := refOf(schemas[name])
+		if !ok || strings.HasPrefix(ref, "#") {
+			continue
+		}
+
+		target, err := b.target(b.root, ref)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, has := b.names[target]; !has {
+			b.names[target] = name
+			adopted = append(adopted, target)
+		}
+	}
+
+	res, err := b.walk(b.root, tree, object)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, target := range adopted {
+		name := b.names[target]
+		file, fragment := splitTarget(target)
+		node, err := b.resolveSchema(file, fragment)
+		if err != nil {
+			return nil, err
+		}
+
+		b.bundled[name], err = b.walk(file, node, schema)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(b.bundled) == 0 {
+		return res, nil
+	}
+
+	doc, ok := res.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s: document is not an object", b.display(b.root))
+	}
+
+	components, _ := doc["components"].(map[string]interface{})
+	if components == nil {
+		components = map[string]interface{}{}
+		doc["components"] = components
+	}
+
+	resSchemas, _ := components["schemas"].(map[string]interface{})
+	if resSchemas == nil {
+		resSchemas = map[string]interface{}{}
+		components["schemas"] = resSchemas
+	}
+
+	for name, node := range b.bundled {
+		resSchemas[name] = node
+	}
+	return res, nil
+}
+
+// walk returns a copy of the node of the file, whose references into other files have been replaced.
+func (b *bundler) walk(file string, node interface{}, k kind) (interface{}, error) {
+	switch n := node.(type) {
+	case map[string]interface{}:
+		if ref, ok := refOf(n); ok && k != opaque && k != schemaMap {
+			return b.replaceRef(file, ref, k)
+		}
+
+		res := make(map[string]interface{}, len(n))
+		for _, key := range gen.SortedKeys(n) {
+			v, err := b.walk(file, n[key], childKind(k, key))
+			if err != nil {
+				return nil, err
+			}
+			res[key] = v
+		}
+		return res, nil
+	case []interface{}:
+		elemKind := k
+		if k == schemaList {
+			elemKind = schema
+		}
+
+		res := make([]interface{}, len(n))
+		for i, elem := range n {
+			v, err := b.walk(file, elem, elemKind)
+			if err != nil {
+				return nil, err
+			}
+			res[i] = v
+		}
+		return res, nil
+	default:
+		return node, nil
+	}
+}
+
+// childKind returns the kind of the value of the key within a node of the given kind.
+func childKind(k kind, key string) kind {
+	switch k {
+	case schema:
+		switch key {
+		case "properties", "patternProperties":
+			return schemaMap
+		case "items", "additionalProperties", "not":
+			return schema
+		case "allOf", "oneOf", "anyOf":
+			return schemaList
+		case "example", "examples", "default", "enum", "const":
+			return opaque
+		}
+		return object
+	case schemaMap:
+		return schema
+	case opaque:
+		return opaque
+	default:
+		// default is no value outside of a schema, but e.g. the default response
+		switch key {
+		case "schema":
+			return schema
+		case "schemas":
+			return schemaMap
+		case "example", "value":
+			return opaque
+		}
+		return object
+	}
+}
+
+// replaceRef returns the replacement of the reference of the file. A local schema reference of the root document
+// is kept. A reference to a schema of another file is replaced by a reference to the bundled component and any
+// other reference, even a local one like #/components/parameters/Limit, by the referenced object, because the
+// model only resolves schema references.
+func (b *bundler) replaceRef(file, ref string, k kind) (interface{}, error) {
+	target, err := b.target(file, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := b.checkCycle(target); err != nil {
+		return nil, err
+	}
+
+	targetFile, fragment := splitTarget(target)
+	if k == schema {
+		if targetFile == b.root {
+			return map[string]interface{}{"$ref": "#" + fragment}, nil
+		}
+
+		name, err := b.component(target)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"$ref": gen.ComponentSchemaPrefix + gen.EscapeToken(name)}, nil
+	}
+
+	if b.inlining[target] {
+		return nil, fmt.Errorf("%s: reference cycle while inlining '%s'", b.display(file), ref)
+	}
+
+	node, err := b.resolve(targetFile, fragment)
+	if err != nil {
+		return nil, err
+	}
+
+	b.inlining[target] = true
+	defer delete(b.inlining, target)
+	return b.walk(targetFile, node, k)
+}
+
+// component returns the name of the component, which contains the referenced schema of another file. The schema
+// is bundled when it is referenced for the first time.
+func (b *bundler) component(target string) (string, error) {
+	if name, has := b.names[target]; has {
+		return name, nil
+	}
+
+	file, fragment := splitTarget(target)
+	node, err := b.resolveSchema(file, fragment)
+	if err != nil {
+		return "", err
+	}
+
+	if b.swagger {
+		node = convertSwaggerSchema(node)
+	}
+
+	name := b.uniqueName(file, fragment)
+	b.names[target] = name
+	b.taken[strings.ToLower(name)] = true
+
+	// register the name first, so that recursive schemas refer to the component itself
+	res, err := b.walk(file, node, schema)
+	if err != nil {
+		return "", err
+	}
+
+	b.bundled[name] = res
+	return name, nil
+}
+
+// resolveSchema is like resolve, but ensures that the referenced schema is an object.
+func (b *bundler) resolveSchema(file, fragment string) (interface{}, error) {
+	node, err := b.resolve(file, fragment)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := node.(map[string]interface{}); !ok {
+		return nil, fmt.Errorf("%s#%s: referenced schema is not an object", b.display(file), fragment)
+	}
+	return node, nil
+}
+
+// uniqueName derives a component name from the last token of the fragment or from the file name, if the
+// whole file is referenced. The name becomes an exported Go identifier, so that a file like user-profile.yaml
+// results in UserProfile. A number is appended, if the name is already taken, ignoring the case.
+func (b *bundler) uniqueName(file, fragment string) string {
+	base := gen.Identifier(strings.TrimSuffix(filepath.Base(file), filepath.Ext(file)))
+	if tokens := strings.Split(fragment, "/"); fragment != "" {
+		base = gen.Identifier(gen.UnescapeToken(tokens[len(tokens)-1]))
+	}
+
+	name := base
+	for i := 2; b.taken[strings.ToLower(name)]; i++ {
+		name = base + strconv.Itoa(i)
+	}
+	return name
+}
+
+// checkCycle follows the chain of references, which starts at the target, and fails, if it ends in itself.
+// Unresolvable references are reported when they are actually resolved.
+func (b *bundler) checkCycle(target string) error {
+	visited := map[string]bool{}
+	var chain []string
+	for {
+		if visited[target] {
+			chain = append(chain, b.displayTarget(target))
+			return fmt.Errorf("reference cycle: %s", strings.Join(chain, " -> "))
+		}
+
+		visited[target] = true
+		chain = append(chain, b.displayTarget(target))
+
+		file, fragment := splitTarget(target)
+		node, err := b.resolve(file, fragment)
+		if err != nil {
+			return nil
+		}
+
+		ref, ok := refOf(node)
+		if !ok {
+			return nil
+		}
+
+		target, err = b.target(file, ref)
+		if err != nil {
+			return nil
+		}
+	}
+}
+
+// target returns the absolute reference target like /abs/user.yaml#/User for the reference of the file.
+func (b *bundler) target(file, ref string) (string, error) {
+	if strings.Contains(ref, "://") {
+		return "", fmt.Errorf("%s: remote reference '%s' is not supported", b.display(file), ref)
+	}
+
+	path, fragment := ref, ""
+	if idx := strings.Index(ref, "#"); idx >= 0 {
+		path, fragment = ref[:idx], ref[idx+1:]
+	}
+
+	if path == "" {
+		path = file
+	} else {
+		path = filepath.FromSlash(path)
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(filepath.Dir(file), path)
+		}
+		path = filepath.Clean(path)
+	}
+
+	// the definitions of a converted root document have been moved
+	if b.swagger && path == b.root {
+		fragment = swaggerFragment(fragment)
+	}
+	return path + "#" + fragment, nil
+}
+
+// resolve returns the node of the file at the JSON pointer fragment.
+func (b *bundler) resolve(file, fragment string) (interface{}, error) {
+	node, err := b.load(file)
+	if err != nil {
+		return nil, err
+	}
+
+	if fragment == "" {
+		return node, nil
+	}
+
+	if !strings.HasPrefix(fragment, "/") {
+		return nil, fmt.Errorf("%s#%s: invalid JSON pointer", b.display(file), fragment)
+	}
+
+	for _, token := range strings.Split(fragment[1:], "/") {
+		token = gen.UnescapeToken(token)
+		switch n := node.(type) {
+		case map[string]interface{}:
+			child, has := n[token]
+			if !has {
+				return nil, fmt.Errorf("%s#%s: unresolvable reference", b.display(file), fragment)
+			}
+			node = child
+		case []interface{}:
+			idx, err := strconv.Atoi(token)
+			if err != nil || idx < 0 || idx >= len(n) {
+				return nil, fmt.Errorf("%s#%s: unresolvable reference", b.display(file), fragment)
+			}
+			node = n[idx]
+		default:
+			return nil, fmt.Errorf("%s#%s: unresolvable reference", b.display(file), fragment)
+		}
+	}
+	return node, nil
+}
+
+// load returns the decoded document of the file, which is read only once.
+func (b *bundler) load(file string) (interface{}, error) {
+	if doc, has := b.docs[file]; has {
+		return doc, nil
+	}
+
+	buf, err := ioutil.ReadFile(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("referenced file %s does not exist", b.display(file))
+		}
+		return nil, err
+	}
+
+	doc, err := decode(b.display(file), buf)
+	if err != nil {
+		return nil, err
+	}
+
+	b.docs[file] = doc
+	return doc, nil
+}
+
+// display returns the path of the file relative to the directory of the root document.
+func (b *bundler) display(file string) string {
+	if rel, err := filepath.Rel(filepath.Dir(b.root), file); err == nil {
+		return filepath.ToSlash(rel)
+	}
+	return file
+}
+
+// displayTarget returns the reference target with a relative path.
+func (b *bundler) displayTarget(target string) string {
+	file, fragment := splitTarget(target)
+	return b.display(file) + "#" + fragment
+}
+
+// splitTarget splits an absolute reference target into its file and fragment.
+func splitTarget(target string) (string, string) {
+	idx := strings.LastIndex(target, "#")
+	return target[:idx], target[idx+1:]
+}
+
+// refOf returns the reference of the node, if it is a reference object.
+func refOf(node interface{}) (string, bool) {
+	m, ok := node.(map[string]interface{})
+	if !ok {
+		return "", false
+	}
+
+	ref, ok := m["$ref"].(string)
+	return ref, ok
+}
+
+// rootSchemas returns the component schemas of the decoded document.
+func rootSchemas(tree interface{}) map[string]interface{} {
+	doc, _ := tree.(map[string]interface{})
+	components, _ := doc["components"].(map[string]interface{})
+	schemas, _ := components["schemas"].(map[string]interface{})
+	return schemas
+}
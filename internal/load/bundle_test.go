@@ -0,0 +1,151 @@
+// Copyright 2020 Torben Schinke
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package load
+
+import (
+	v3 "github.com/golangee/openapi/v3"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBundleFile(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"openapi.yaml":              externalSpec,
+		"schemas/user.yaml":         "User:\n  type: object\n  properties:\n    friends:\n      type: array\n      items:\n        $ref: '#/User'\n    address:\n      $ref: ./address.yaml\n    profile:\n      $ref: ./user-profile.yaml\n    pageSize:\n      $ref: ../common.types.yaml#/page-size\n",
+		"schemas/address.yaml":      "type: object\nproperties:\n  city:\n    type: string\n",
+		"params.yaml":               "Limit:\n  name: limit\n  in: query\n  schema:\n    type: integer\n",
+		"schemas/user-profile.yaml": "type: object\nproperties:\n  nick:\n    type: string\n",
+		"common.types.yaml":         "page-size:\n  type: integer\n",
+	})
+	defer os.RemoveAll(dir)
+
+	buf, err := BundleFile(filepath.Join(dir, "openapi.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	doc, err := v3.FromJson(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, has := doc.Components.Schemas["Address2"]; !has {
+		t.Fatalf("expected the bundled schema Address2 next to Address")
+	}
+
+	for _, name := range []string{"UserProfile", "PageSize"} {
+		if _, has := doc.Components.Schemas[name]; !has {
+			t.Fatalf("expected the bundled schema %s as Go identifier", name)
+		}
+	}
+
+	if ref := doc.Components.Schemas["User"].Properties["friends"].Items.Schema.Ref; ref == nil || *ref != "#/components/schemas/User" {
+		t.Fatalf("expected the recursive reference to be bundled")
+	}
+
+	if ref := doc.Paths["/users"].Get.Responses["200"].Content["application/json"].Schema.Items.Schema.Ref; ref == nil || *ref != "#/components/schemas/User" {
+		t.Fatalf("expected the reference to the adopted component User")
+	}
+
+	if params := doc.Paths["/users"].Get.Parameters; len(params) != 2 || params[0].Name != "limit" || params[1].Name != "offset" {
+		t.Fatalf("expected the inlined parameters of the other file and of the document itself")
+	}
+
+	if _, has := doc.Paths["/users"].Get.Responses["default"].Content["application/json"]; !has {
+		t.Fatalf("expected the inlined response of the document itself")
+	}
+}
+
+func TestBundleCycle(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"a.yaml": "X:\n  $ref: ./b.yaml#/Y\n",
+		"b.yaml": "Y:\n  $ref: ./a.yaml#/X\n",
+	})
+	defer os.RemoveAll(dir)
+
+	cyclic := "openapi: 3.0.1\ninfo: {title: c, version: \"1\"}\npaths: {}\ncomponents:\n  schemas:\n    A:\n      $ref: ./a.yaml#/X\n"
+	if _, err := Bundle(filepath.Join(dir, "cyclic.yaml"), []byte(cyclic)); err == nil || !strings.Contains(err.Error(), "reference cycle") {
+		t.Fatalf("expected a reference cycle but got %v", err)
+	}
+}
+
+// writeFiles writes the files by their slash separated paths into a new temporary directory.
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "openapi-client")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, content := range files {
+		fname := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(fname), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(fname, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+const externalSpec = `
+openapi: 3.0.1
+info:
+  title: test
+  version: ""
+paths:
+  /users:
+    get:
+      parameters:
+        - $ref: ./params.yaml#/Limit
+        - $ref: '#/components/parameters/Offset'
+      responses:
+        200:
+          description: ""
+          content:
+            application/json:
+              schema:
+                type: array
+                items:
+                  $ref: ./schemas/user.yaml#/User
+        default:
+          $ref: '#/components/responses/Error'
+components:
+  parameters:
+    Offset:
+      name: offset
+      in: query
+      schema:
+        type: integer
+  responses:
+    Error:
+      description: ""
+      content:
+        application/json:
+          schema:
+            type: string
+  schemas:
+    User:
+      $ref: ./schemas/user.yaml#/User
+    Address:
+      type: object
+      properties:
+        street:
+          type: string
+`
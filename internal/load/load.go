@@ -0,0 +1,414 @@
+// Copyright 2020 Torben Schinke
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package load reads specifications in JSON or YAML format.
+package load
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"io"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Format of a specification document.
+type Format int
+
+const (
+	JSON Format = iota
+	YAML
+)
+
+// DetectFormat determines the format by the file extension of name. If the extension is unknown, e.g. because
+// the document has no name, a document starting with { is JSON and any other document YAML.
+func DetectFormat(name string, buf []byte) Format {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".json":
+		return JSON
+	case ".yaml", ".yml":
+		return YAML
+	}
+
+	if bytes.HasPrefix(bytes.TrimSpace(buf), []byte("{")) {
+		return JSON
+	}
+	return YAML
+}
+
+// A SyntaxError locates an invalid document position by line and column, both starting at 1. Column is 0 if
+// it is unknown.
+type SyntaxError struct {
+	Name    string
+	Line    int
+	Column  int
+	Message string
+	// Excerpt contains the numbered lines around the position.
+	Excerpt string
+}
+
+func (e *SyntaxError) Error() string {
+	sb := &strings.Builder{}
+	if e.Name != "" {
+		sb.WriteString(e.Name + ":")
+	}
+	sb.WriteString(strconv.Itoa(e.Line) + ":")
+	if e.Column > 0 {
+		sb.WriteString(strconv.Itoa(e.Column) + ":")
+	}
+	sb.WriteString(" " + e.Message)
+	if e.Excerpt != "" {
+		sb.WriteString("\n" + e.Excerpt)
+	}
+	return sb.String()
+}
+
+// newSyntaxError creates a syntax error with an excerpt of the document.
+func newSyntaxError(name string, buf []byte, line, column int, message string) *SyntaxError {
+	return &SyntaxError{
+		Name:    name,
+		Line:    line,
+		Column:  column,
+		Message: message,
+		Excerpt: excerpt(buf, line, column),
+	}
+}
+
+// ToJSON returns the document as JSON. A YAML document is converted, a JSON document is validated and returned
+// as is. The name is used to detect the format and to locate errors and may be empty.
+func ToJSON(name string, buf []byte) ([]byte, error) {
+	tree, err := decode(name, buf)
+	if err != nil {
+		return nil, err
+	}
+
+	if DetectFormat(name, buf) == JSON {
+		return buf, nil
+	}
+	return json.Marshal(tree)
+}
+
+// decode parses the document into the generic tree of maps, slices and scalars. Numbers of JSON documents are
+// kept as json.Number, so that large integers do not lose their precision.
+func decode(name string, buf []byte) (interface{}, error) {
+	if DetectFormat(name, buf) == YAML {
+		return decodeYAML(name, buf)
+	}
+
+	var tree interface{}
+	dec := json.NewDecoder(bytes.NewReader(buf))
+	dec.UseNumber()
+	if err := dec.Decode(&tree); err != nil {
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			line, column := position(buf, syntaxErr.Offset)
+			return nil, newSyntaxError(name, buf, line, column, syntaxErr.Error())
+		}
+		return nil, err
+	}
+
+	if _, err := dec.Token(); err != io.EOF {
+		rest := bytes.TrimLeft(buf[dec.InputOffset():], " \t\r\n")
+		line, column := position(buf, int64(len(buf)-len(rest)+1))
+		return nil, newSyntaxError(name, buf, line, column, "invalid character after top-level value")
+	}
+	return tree, nil
+}
+
+var yamlError = regexp.MustCompile(`^yaml: (?:line (\d+): )?(.*)$`)
+
+// stringKeys contains the keywords of OpenAPI, Swagger and JSON schema, whose values are always strings. Their plain
+// scalars keep the source text, so that e.g. version: 1.0 does not become the number 1.
+var stringKeys = map[string]bool{
+	"openapi": true, "swagger": true, "version": true, "title": true, "description": true, "summary": true,
+	"termsOfService": true, "url": true, "email": true, "operationId": true, "name": true, "in": true,
+	"style": true, "type": true, "format": true, "pattern": true, "$ref": true, "propertyName": true,
+	"discriminator": true, "host": true, "basePath": true, "collectionFormat": true, "tags": true,
+	"produces": true, "consumes": true, "schemes": true,
+}
+
+// stringListKeys contains the keywords, whose values are only strings if they are lists. The required property
+// names of a schema keep the source text, but the required flag of a parameter or request body stays a boolean.
+var stringListKeys = map[string]bool{
+	"required": true,
+}
+
+// isLiteralKey checks if the value of the key is arbitrary user data, like an example or an extension. Such values
+// are decoded by their yaml tags only.
+func isLiteralKey(key string) bool {
+	switch key {
+	case "example", "examples", "default", "enum":
+		return true
+	}
+	return strings.HasPrefix(key, "x-")
+}
+
+// decodeYAML parses the YAML document into the generic tree of maps, slices and scalars, as encoding/json
+// would decode the equivalent JSON document.
+func decodeYAML(name string, buf []byte) (interface{}, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(buf, &root); err != nil {
+		if m := yamlError.FindStringSubmatch(err.Error()); m != nil {
+			line, _ := strconv.Atoi(m[1])
+			line, column := yamlPosition(buf, err, line)
+			return nil, newSyntaxError(name, buf, line, column, m[2])
+		}
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	if root.Kind == 0 {
+		return nil, newSyntaxError(name, buf, 1, 1, "empty document")
+	}
+
+	tree, err := yamlValue(&root, "")
+	if err != nil {
+		var syntaxErr *SyntaxError
+		if errors.As(err, &syntaxErr) {
+			return nil, newSyntaxError(name, buf, syntaxErr.Line, syntaxErr.Column, syntaxErr.Message)
+		}
+		return nil, err
+	}
+	return tree, nil
+}
+
+// yamlValue converts the node into its JSON compatible value. The key of the enclosing mapping entry decides, if
+// a plain scalar must be kept as string, see stringKeys.
+func yamlValue(node *yaml.Node, key string) (interface{}, error) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		return yamlValue(node.Content[0], key)
+	case yaml.AliasNode:
+		return yamlValue(node.Alias, key)
+	case yaml.MappingNode:
+		obj := map[string]interface{}{}
+		if err := yamlMapping(node, key, obj); err != nil {
+			return nil, err
+		}
+		return obj, nil
+	case yaml.SequenceNode:
+		list := make([]interface{}, 0, len(node.Content))
+		for _, child := range node.Content {
+			if stringListKeys[key] && isPlainScalar(child) {
+				list = append(list, child.Value)
+				continue
+			}
+
+			v, err := yamlValue(child, key)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+		return list, nil
+	case yaml.ScalarNode:
+		switch node.ShortTag() {
+		case "!!str", "!!timestamp", "!!binary":
+			return node.Value, nil
+		case "!!null":
+			return nil, nil
+		}
+
+		if stringKeys[key] && isPlainScalar(node) {
+			return node.Value, nil
+		}
+
+		var v interface{}
+		if err := node.Decode(&v); err != nil {
+			return nil, &SyntaxError{Line: node.Line, Column: node.Column, Message: err.Error()}
+		}
+		return v, nil
+	default:
+		return nil, &SyntaxError{Line: node.Line, Column: node.Column, Message: "unsupported yaml node"}
+	}
+}
+
+// isPlainScalar checks if the node is a number or boolean scalar without an explicit tag, whose source text is
+// kept for string keywords.
+func isPlainScalar(node *yaml.Node) bool {
+	if node.Kind != yaml.ScalarNode || node.Style&yaml.TaggedStyle != 0 {
+		return false
+	}
+
+	switch node.ShortTag() {
+	case "!!int", "!!float", "!!bool":
+		return true
+	}
+	return false
+}
+
+// yamlMapping puts the entries of the mapping node into obj. Keys must be scalars, which are taken literally, so
+// that e.g. unquoted response codes like 200 become the key "200". Merge keys (<<) are resolved. Within literal
+// values, the key of the mapping is kept for all nested entries.
+func yamlMapping(node *yaml.Node, key string, obj map[string]interface{}) error {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		k, value := node.Content[i], node.Content[i+1]
+		if k.Kind != yaml.ScalarNode {
+			return &SyntaxError{Line: k.Line, Column: k.Column, Message: "mapping key must be a scalar"}
+		}
+
+		if k.ShortTag() == "!!merge" {
+			if err := yamlMerge(value, key, obj); err != nil {
+				return err
+			}
+			continue
+		}
+
+		childKey := k.Value
+		if isLiteralKey(key) {
+			childKey = key
+		}
+
+		v, err := yamlValue(value, childKey)
+		if err != nil {
+			return err
+		}
+		obj[k.Value] = v
+	}
+	return nil
+}
+
+// yamlMerge puts the entries of the merged mapping or sequence of mappings into obj, without replacing existing
+// entries.
+func yamlMerge(node *yaml.Node, key string, obj map[string]interface{}) error {
+	for node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+
+	var sources []*yaml.Node
+	switch node.Kind {
+	case yaml.MappingNode:
+		sources = []*yaml.Node{node}
+	case yaml.SequenceNode:
+		sources = node.Content
+	default:
+		return &SyntaxError{Line: node.Line, Column: node.Column, Message: "merge value must be a mapping"}
+	}
+
+	for _, source := range sources {
+		v, err := yamlValue(source, key)
+		if err != nil {
+			return err
+		}
+
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return &SyntaxError{Line: source.Line, Column: source.Column, Message: "merge value must be a mapping"}
+		}
+
+		for key, value := range m {
+			if _, has := obj[key]; !has {
+				obj[key] = value
+			}
+		}
+	}
+	return nil
+}
+
+// yamlPosition locates a syntax error of yaml.v3, which reports at most the line. The document is truncated until
+// the shortest prefix fails with the same error, whose end is where the parser detected the error. Without a
+// reported line, the line is searched first. Without any match, the column falls back to the first non-blank
+// character of the line.
+func yamlPosition(buf []byte, err error, line int) (int, int) {
+	fails := func(n int) bool {
+		prefixErr := yaml.Unmarshal(buf[:n], &yaml.Node{})
+		return prefixErr != nil && prefixErr.Error() == err.Error()
+	}
+
+	lines := bytes.SplitAfter(buf, []byte("\n"))
+	lineEnd := func(line int) int {
+		n := 0
+		for _, l := range lines[:line] {
+			n += len(l)
+		}
+		return n
+	}
+
+	if line == 0 {
+		line = sort.Search(len(lines), func(i int) bool { return fails(lineEnd(i + 1)) }) + 1
+	}
+
+	if line > len(lines) {
+		line = len(lines)
+	}
+
+	if line < 1 {
+		return line, 0
+	}
+
+	start := lineEnd(line - 1)
+	text := bytes.TrimRight(lines[line-1], "\r\n")
+	for i := 1; i <= len(text); i++ {
+		if fails(start + i) {
+			return line, i
+		}
+	}
+
+	return line, len(text) - len(bytes.TrimLeft(text, " \t")) + 1
+}
+
+// position converts the offset of encoding/json, which includes the invalid byte, into a line and column, both
+// starting at 1.
+func position(buf []byte, offset int64) (int, int) {
+	idx := int(offset) - 1
+	if idx < 0 {
+		idx = 0
+	}
+
+	if idx > len(buf) {
+		idx = len(buf)
+	}
+
+	prefix := buf[:idx]
+	line := bytes.Count(prefix, []byte("\n")) + 1
+	column := idx - bytes.LastIndexByte(prefix, '\n')
+	return line, column
+}
+
+// excerpt returns the line and its predecessor prefixed with their line number, followed by a marker below the
+// column, if it is known.
+func excerpt(buf []byte, line, column int) string {
+	lines := strings.Split(string(buf), "\n")
+	if line < 1 || line > len(lines) {
+		return ""
+	}
+
+	sb := &strings.Builder{}
+	for i := line - 1; i <= line; i++ {
+		if i < 1 {
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("%4d | %s\n", i, strings.TrimRight(lines[i-1], "\r")))
+	}
+
+	if column > 0 {
+		// keep tabs, so that the marker is aligned below the column
+		marker := []rune(lines[line-1])
+		if column-1 < len(marker) {
+			marker = marker[:column-1]
+		}
+		for i, r := range marker {
+			if r != '\t' {
+				marker[i] = ' '
+			}
+		}
+		sb.WriteString("     | " + string(marker) + "^\n")
+	}
+	return sb.String()
+}
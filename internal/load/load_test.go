@@ -0,0 +1,134 @@
+// Copyright 2020 Torben Schinke
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package load
+
+import (
+	"errors"
+	v3 "github.com/golangee/openapi/v3"
+	"strings"
+	"testing"
+)
+
+func TestToJSON(t *testing.T) {
+	buf, err := ToJSON("", []byte(yamlSpec))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	doc, err := v3.FromJson(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if doc.Info.Version != "1.0" || doc.Info.Title != "2020" {
+		t.Fatalf("expected the version and title as source text but got %s", buf)
+	}
+
+	if _, has := doc.Paths["/pets"].Get.Responses["200"]; !has {
+		t.Fatalf("expected the unquoted response code 200")
+	}
+
+	put := doc.Paths["/pets/{petId}"].Put
+	if len(put.Parameters) != 1 || !put.Parameters[0].Required || !put.RequestBody.Required {
+		t.Fatalf("expected the required flags of the parameter and the request body")
+	}
+
+	pet := doc.Components.Schemas["Pet"]
+	if pet.Type != v3.Object || len(pet.Required) != 2 || pet.Required[1] != "true" {
+		t.Fatalf("expected the merged type and the required property names as source text but got %s", buf)
+	}
+
+	for _, expected := range []string{`"born":{"example":"2020-01-01","type":"string"}`, `"example":1`} {
+		if !strings.Contains(string(buf), expected) {
+			t.Fatalf("expected %s in %s", expected, buf)
+		}
+	}
+}
+
+func TestSyntaxErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		spec   string
+		line   int
+		column int
+	}{
+		{name: "pets.yaml", spec: "openapi: 3.0.1\ninfo:\n  title: x\n   version: 1\n", line: 4, column: 11},
+		{name: "pets.yaml", spec: "openapi: 3.0.1\ninfo:\n  title: @x\n", line: 3, column: 10},
+		{name: "pets.json", spec: "{\n  \"info\": {,}\n}", line: 2, column: 12},
+	}
+
+	for _, tt := range tests {
+		_, err := ToJSON(tt.name, []byte(tt.spec))
+		var syntaxErr *SyntaxError
+		if !errors.As(err, &syntaxErr) || syntaxErr.Line != tt.line || syntaxErr.Column != tt.column {
+			t.Fatalf("expected a syntax error in line %d column %d but got %v", tt.line, tt.column, err)
+		}
+	}
+}
+
+const yamlSpec = `
+openapi: 3.0.1
+info:
+  title: 2020
+  version: 1.0
+paths:
+  /pets:
+    get:
+      responses:
+        200:
+          description: ""
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/Pet'
+  /pets/{petId}:
+    put:
+      parameters:
+        - name: petId
+          in: path
+          required: true
+          schema:
+            type: integer
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              $ref: '#/components/schemas/Pet'
+      responses:
+        204:
+          description: ""
+components:
+  schemas:
+    Named: &named
+      type: object
+      properties:
+        name:
+          type: string
+    Pet:
+      <<: *named
+      required: [name, true]
+      properties:
+        name:
+          type: string
+        "true":
+          type: boolean
+        born:
+          type: string
+          example: 2020-01-01
+    Ratio:
+      type: number
+      example: 1.0
+`
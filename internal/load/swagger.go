@@ -0,0 +1,541 @@
+// Copyright 2020 Torben Schinke
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package load
+
+import (
+	"fmt"
+	"github.com/golangee/openapi-client/internal/gen"
+	"strings"
+)
+
+const (
+	swaggerDefinitionPrefix = "#/definitions/"
+	defaultMediaType        = "application/json"
+)
+
+// swaggerMethods contains the operations of a Swagger 2.0 path item.
+var swaggerMethods = []string{"get", "put", "post", "delete", "options", "head", "patch"}
+
+// swaggerParameterFields are copied from a parameter or items object into the schema of a parameter.
+var swaggerParameterFields = []string{
+	"type", "format", "default", "maximum", "exclusiveMaximum", "minimum", "exclusiveMinimum", "maxLength",
+	"minLength", "pattern", "maxItems", "minItems", "uniqueItems", "enum", "multipleOf",
+}
+
+// isSwagger checks if the decoded document is a Swagger 2.0 document.
+func isSwagger(tree interface{}) bool {
+	doc, _ := tree.(map[string]interface{})
+	_, has := doc["swagger"]
+	return has
+}
+
+// convertSwagger converts the decoded Swagger 2.0 document into an OpenAPI 3 document. Definitions become
+// component schemas, body and formData parameters become request bodies, produces and consumes become the
+// media types of the responses and request bodies and security definitions become security schemes. Referenced
+// parameters and responses are inlined.
+func convertSwagger(tree interface{}) (map[string]interface{}, error) {
+	doc := tree.(map[string]interface{})
+	if version, _ := doc["swagger"].(string); version != "2.0" {
+		return nil, fmt.Errorf("unsupported swagger version '%v'", doc["swagger"])
+	}
+
+	res := map[string]interface{}{"openapi": "3.0.1"}
+	for _, key := range gen.SortedKeys(doc) {
+		switch key {
+		case "info", "tags", "externalDocs", "security":
+			res[key] = doc[key]
+		default:
+			if strings.HasPrefix(key, "x-") {
+				res[key] = doc[key]
+			}
+		}
+	}
+
+	if servers := swaggerServers(doc); len(servers) > 0 {
+		res["servers"] = servers
+	}
+
+	components := map[string]interface{}{}
+	if definitions, ok := doc["definitions"].(map[string]interface{}); ok {
+		schemas := map[string]interface{}{}
+		for name, definition := range definitions {
+			schemas[name] = convertSwaggerSchema(definition)
+		}
+		components["schemas"] = schemas
+	}
+
+	if definitions, ok := doc["securityDefinitions"].(map[string]interface{}); ok {
+		schemes := map[string]interface{}{}
+		for _, name := range gen.SortedKeys(definitions) {
+			scheme, err := convertSecurityScheme(name, definitions[name])
+			if err != nil {
+				return nil, err
+			}
+			schemes[name] = scheme
+		}
+		components["securitySchemes"] = schemes
+	}
+
+	if len(components) > 0 {
+		res["components"] = components
+	}
+
+	paths := map[string]interface{}{}
+	if items, ok := doc["paths"].(map[string]interface{}); ok {
+		for _, path := range gen.SortedKeys(items) {
+			item, err := convertPathItem(doc, path, items[path])
+			if err != nil {
+				return nil, err
+			}
+			paths[path] = item
+		}
+	}
+	res["paths"] = paths
+
+	return res, nil
+}
+
+// swaggerServers derives the server urls from the host, the base path and the schemes.
+func swaggerServers(doc map[string]interface{}) []interface{} {
+	host, _ := doc["host"].(string)
+	basePath, _ := doc["basePath"].(string)
+	if host == "" {
+		if basePath == "" {
+			return nil
+		}
+		return []interface{}{map[string]interface{}{"url": basePath}}
+	}
+
+	schemes := stringList(doc["schemes"])
+	if len(schemes) == 0 {
+		schemes = []string{"https"}
+	}
+
+	var res []interface{}
+	for _, scheme := range schemes {
+		res = append(res, map[string]interface{}{"url": scheme + "://" + host + basePath})
+	}
+	return res
+}
+
+// convertPathItem converts each operation of the path item. Parameters of the path item are declared by each
+// operation, because the model has no path item parameters.
+func convertPathItem(doc map[string]interface{}, path string, node interface{}) (map[string]interface{}, error) {
+	item, ok := node.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("#/paths/%s: path item is not an object", gen.EscapeToken(path))
+	}
+
+	if _, has := item["$ref"]; has {
+		return nil, fmt.Errorf("#/paths/%s: path item references are not supported in swagger documents", gen.EscapeToken(path))
+	}
+
+	common, _ := item["parameters"].([]interface{})
+	res := map[string]interface{}{}
+	for _, method := range swaggerMethods {
+		op, ok := item[method].(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		pointer := "#/paths/" + gen.EscapeToken(path) + "/" + method
+		converted, err := convertOperation(doc, pointer, common, op)
+		if err != nil {
+			return nil, err
+		}
+		res[method] = converted
+	}
+	return res, nil
+}
+
+// convertOperation converts the parameters and the responses of the operation and copies everything else.
+func convertOperation(doc map[string]interface{}, pointer string, common []interface{}, op map[string]interface{}) (map[string]interface{}, error) {
+	res := map[string]interface{}{}
+	for _, key := range gen.SortedKeys(op) {
+		switch key {
+		case "tags", "summary", "description", "externalDocs", "operationId", "deprecated", "security":
+			res[key] = op[key]
+		default:
+			if strings.HasPrefix(key, "x-") {
+				res[key] = op[key]
+			}
+		}
+	}
+
+	consumes := mediaTypes(doc, op, "consumes")
+	produces := mediaTypes(doc, op, "produces")
+
+	params, err := operationParameters(doc, pointer, common, op)
+	if err != nil {
+		return nil, err
+	}
+
+	var converted, formRequired []interface{}
+	formProperties := map[string]interface{}{}
+	multipart := false
+	for _, param := range params {
+		switch param["in"] {
+		case "body":
+			content := map[string]interface{}{}
+			for _, mediaType := range consumes {
+				content[mediaType] = map[string]interface{}{"schema": convertSwaggerSchema(param["schema"])}
+			}
+
+			body := map[string]interface{}{"content": content}
+			copyFields(body, param, "description", "required")
+			res["requestBody"] = body
+		case "formData":
+			schema := parameterSchema(param)
+			if schema["type"] == "file" {
+				schema["type"] = "string"
+				schema["format"] = "binary"
+				multipart = true
+			}
+
+			formProperties[fmt.Sprint(param["name"])] = schema
+			if required, _ := param["required"].(bool); required {
+				formRequired = append(formRequired, param["name"])
+			}
+		case "query", "header", "path":
+			converted = append(converted, convertParameter(param))
+		default:
+			return nil, fmt.Errorf("%s: unsupported parameter location '%v'", pointer, param["in"])
+		}
+	}
+
+	if len(converted) > 0 {
+		res["parameters"] = converted
+	}
+
+	if len(formProperties) > 0 {
+		if _, has := res["requestBody"]; has {
+			return nil, fmt.Errorf("%s: body and formData parameters cannot be mixed", pointer)
+		}
+
+		form := map[string]interface{}{"type": "object", "properties": formProperties}
+		if len(formRequired) > 0 {
+			form["required"] = formRequired
+		}
+
+		mediaType := gen.FormMediaType
+		if multipart || containsString(consumes, gen.MultipartMediaType) {
+			mediaType = gen.MultipartMediaType
+		}
+		res["requestBody"] = map[string]interface{}{
+			"required": len(formRequired) > 0,
+			"content":  map[string]interface{}{mediaType: map[string]interface{}{"schema": form}},
+		}
+	}
+
+	responses := map[string]interface{}{}
+	if items, ok := op["responses"].(map[string]interface{}); ok {
+		for _, code := range gen.SortedKeys(items) {
+			if strings.HasPrefix(code, "x-") {
+				continue
+			}
+
+			response, err := convertResponse(doc, pointer+"/responses/"+gen.EscapeToken(code), produces, items[code])
+			if err != nil {
+				return nil, err
+			}
+			responses[code] = response
+		}
+	}
+	res["responses"] = responses
+
+	return res, nil
+}
+
+// operationParameters resolves the parameters of the path item and of the operation, which overrides a
+// parameter of the path item with the same name and location.
+func operationParameters(doc map[string]interface{}, pointer string, common []interface{}, op map[string]interface{}) ([]map[string]interface{}, error) {
+	own, _ := op["parameters"].([]interface{})
+
+	var res []map[string]interface{}
+	index := map[string]int{}
+	for i, node := range append(append([]interface{}{}, common...), own...) {
+		param, err := resolveSwaggerRef(doc, node, "#/parameters/")
+		if err != nil {
+			return nil, fmt.Errorf("%s/parameters/%d: %w", pointer, i, err)
+		}
+
+		key := fmt.Sprintf("%v/%v", param["in"], param["name"])
+		if idx, has := index[key]; has {
+			res[idx] = param
+			continue
+		}
+
+		index[key] = len(res)
+		res = append(res, param)
+	}
+	return res, nil
+}
+
+// convertParameter converts a query, header or path parameter, whose type declaration becomes its schema.
+func convertParameter(param map[string]interface{}) map[string]interface{} {
+	res := map[string]interface{}{}
+	copyFields(res, param, "name", "in", "description", "required", "deprecated", "allowEmptyValue")
+	for _, key := range gen.SortedKeys(param) {
+		if strings.HasPrefix(key, "x-") {
+			res[key] = param[key]
+		}
+	}
+
+	res["schema"] = parameterSchema(param)
+	if param["type"] == "array" {
+		style, explode := collectionStyle(param["in"], param["collectionFormat"])
+		res["style"] = style
+		res["explode"] = explode
+	}
+	return res
+}
+
+// collectionStyle maps the collectionFormat of an array parameter to the style and explode values of OpenAPI 3.
+func collectionStyle(in, collectionFormat interface{}) (string, bool) {
+	if in != "query" {
+		return "simple", false
+	}
+
+	switch collectionFormat {
+	case "multi":
+		return "form", true
+	case "ssv":
+		return "spaceDelimited", false
+	case "pipes":
+		return "pipeDelimited", false
+	default:
+		return "form", false
+	}
+}
+
+// parameterSchema converts the type declaration of a parameter or of its items into a schema.
+func parameterSchema(param map[string]interface{}) map[string]interface{} {
+	schema := map[string]interface{}{}
+	copyFields(schema, param, swaggerParameterFields...)
+	if items, ok := param["items"].(map[string]interface{}); ok {
+		schema["items"] = parameterSchema(items)
+	}
+	return schema
+}
+
+// convertResponse converts the schema of the response into the content of each media type.
+func convertResponse(doc map[string]interface{}, pointer string, produces []string, node interface{}) (map[string]interface{}, error) {
+	response, err := resolveSwaggerRef(doc, node, "#/responses/")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", pointer, err)
+	}
+
+	res := map[string]interface{}{"description": response["description"]}
+	if res["description"] == nil {
+		res["description"] = ""
+	}
+
+	if schema, has := response["schema"]; has {
+		content := map[string]interface{}{}
+		for _, mediaType := range produces {
+			content[mediaType] = map[string]interface{}{"schema": convertSwaggerSchema(schema)}
+		}
+		res["content"] = content
+	}
+
+	if headers, ok := response["headers"].(map[string]interface{}); ok {
+		converted := map[string]interface{}{}
+		for name, node := range headers {
+			header, _ := node.(map[string]interface{})
+			h := map[string]interface{}{"schema": parameterSchema(header)}
+			copyFields(h, header, "description")
+			converted[name] = h
+		}
+		res["headers"] = converted
+	}
+	return res, nil
+}
+
+// convertSecurityScheme converts a security definition into a security scheme.
+func convertSecurityScheme(name string, node interface{}) (map[string]interface{}, error) {
+	def, _ := node.(map[string]interface{})
+	res := map[string]interface{}{}
+	copyFields(res, def, "description")
+
+	switch def["type"] {
+	case "basic":
+		res["type"] = "http"
+		res["scheme"] = "basic"
+	case "apiKey":
+		res["type"] = "apiKey"
+		copyFields(res, def, "name", "in")
+	case "oauth2":
+		flow := map[string]interface{}{"scopes": def["scopes"]}
+		if flow["scopes"] == nil {
+			flow["scopes"] = map[string]interface{}{}
+		}
+		copyFields(flow, def, "authorizationUrl", "tokenUrl")
+
+		flows := map[string]string{
+			"implicit":    "implicit",
+			"password":    "password",
+			"application": "clientCredentials",
+			"accessCode":  "authorizationCode",
+		}
+		flowName, ok := flows[fmt.Sprint(def["flow"])]
+		if !ok {
+			return nil, fmt.Errorf("#/securityDefinitions/%s: unsupported oauth2 flow '%v'", gen.EscapeToken(name), def["flow"])
+		}
+
+		res["type"] = "oauth2"
+		res["flows"] = map[string]interface{}{flowName: flow}
+	default:
+		return nil, fmt.Errorf("#/securityDefinitions/%s: unsupported type '%v'", gen.EscapeToken(name), def["type"])
+	}
+	return res, nil
+}
+
+// convertSwaggerSchema returns a copy of the schema, whose references to definitions refer to component schemas.
+// A file becomes a binary string, x-nullable becomes nullable and a discriminator declares its property name.
+func convertSwaggerSchema(node interface{}) interface{} {
+	switch n := node.(type) {
+	case map[string]interface{}:
+		res := make(map[string]interface{}, len(n))
+		for key, value := range n {
+			switch key {
+			case "example", "default", "enum", "x-example":
+				res[key] = value
+			case "$ref":
+				res[key] = convertSwaggerRef(value)
+			case "x-nullable":
+				res["nullable"] = value
+			case "discriminator":
+				if property, ok := value.(string); ok {
+					res[key] = map[string]interface{}{"propertyName": property}
+				} else {
+					res[key] = value
+				}
+			case "properties":
+				props, _ := value.(map[string]interface{})
+				converted := make(map[string]interface{}, len(props))
+				for name, prop := range props {
+					converted[name] = convertSwaggerSchema(prop)
+				}
+				res[key] = converted
+			default:
+				res[key] = convertSwaggerSchema(value)
+			}
+		}
+
+		if res["type"] == "file" {
+			res["type"] = "string"
+			res["format"] = "binary"
+		}
+		return res
+	case []interface{}:
+		res := make([]interface{}, len(n))
+		for i, elem := range n {
+			res[i] = convertSwaggerSchema(elem)
+		}
+		return res
+	default:
+		return node
+	}
+}
+
+// convertSwaggerRef converts a reference to a definition into a reference to a component schema.
+func convertSwaggerRef(ref interface{}) interface{} {
+	s, ok := ref.(string)
+	if !ok {
+		return ref
+	}
+
+	if idx := strings.Index(s, swaggerDefinitionPrefix); idx >= 0 {
+		return s[:idx] + gen.ComponentSchemaPrefix + s[idx+len(swaggerDefinitionPrefix):]
+	}
+	return s
+}
+
+// resolveSwaggerRef returns the object itself or, if it is a local reference with the prefix, the referenced object.
+func resolveSwaggerRef(doc map[string]interface{}, node interface{}, prefix string) (map[string]interface{}, error) {
+	obj, ok := node.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected an object")
+	}
+
+	ref, ok := obj["$ref"].(string)
+	if !ok {
+		return obj, nil
+	}
+
+	if !strings.HasPrefix(ref, prefix) {
+		return nil, fmt.Errorf("unsupported reference '%s'", ref)
+	}
+
+	section, _ := doc[strings.Split(prefix, "/")[1]].(map[string]interface{})
+	target, ok := section[gen.UnescapeToken(ref[len(prefix):])].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unresolvable reference '%s'", ref)
+	}
+	return target, nil
+}
+
+// mediaTypes returns the declared media types of the operation, which default to the declaration of the document
+// and finally to application/json.
+func mediaTypes(doc, op map[string]interface{}, key string) []string {
+	if types := stringList(op[key]); len(types) > 0 {
+		return types
+	}
+
+	if types := stringList(doc[key]); len(types) > 0 {
+		return types
+	}
+	return []string{defaultMediaType}
+}
+
+// copyFields copies the existing fields of src into dst.
+func copyFields(dst, src map[string]interface{}, keys ...string) {
+	for _, key := range keys {
+		if v, has := src[key]; has {
+			dst[key] = v
+		}
+	}
+}
+
+// stringList returns the strings of the decoded list.
+func stringList(node interface{}) []string {
+	list, _ := node.([]interface{})
+	var res []string
+	for _, v := range list {
+		if s, ok := v.(string); ok {
+			res = append(res, s)
+		}
+	}
+	return res
+}
+
+// containsString checks if the list contains the string.
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// swaggerFragment converts a fragment into the root document of a converted Swagger 2.0 document.
+func swaggerFragment(fragment string) string {
+	const definitions = "/definitions/"
+	if strings.HasPrefix(fragment, definitions) {
+		return "/components/schemas/" + fragment[len(definitions):]
+	}
+	return fragment
+}
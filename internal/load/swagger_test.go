@@ -0,0 +1,210 @@
+// Copyright 2020 Torben Schinke
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package load
+
+import (
+	v3 "github.com/golangee/openapi/v3"
+	"testing"
+)
+
+func TestConvertSwagger(t *testing.T) {
+	buf, err := Bundle("", []byte(swaggerSpec))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	doc, err := v3.FromJson(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ref := doc.Components.Schemas["Pet"].Properties["tags"].Items.Schema.Ref; ref == nil || *ref != "#/components/schemas/Tag" {
+		t.Fatalf("expected the definition reference to be converted")
+	}
+
+	list := doc.Paths["/pets"].Get
+	if len(list.Parameters) != 2 || list.Parameters[0].Name != "limit" || list.Parameters[1].Style != "form" || *list.Parameters[1].Explode {
+		t.Fatalf("expected the referenced limit and the csv tags parameters")
+	}
+
+	if _, has := list.Responses["200"].Content["application/json"]; !has {
+		t.Fatalf("expected the produced media type")
+	}
+
+	if _, has := doc.Paths["/pets"].Post.RequestBody.Content["application/json"]; !has {
+		t.Fatalf("expected the body parameter as request body")
+	}
+
+	upload := doc.Paths["/pets/{petId}/image"].Post
+	if len(upload.Parameters) != 1 || upload.Parameters[0].Name != "petId" {
+		t.Fatalf("expected the path parameter of the path item")
+	}
+
+	form, has := upload.RequestBody.Content["multipart/form-data"]
+	if !has || form.Schema.Properties["file"].Format != "binary" {
+		t.Fatalf("expected the file as binary multipart property")
+	}
+}
+
+func TestConvertSwaggerYAML(t *testing.T) {
+	buf, err := Bundle("", []byte(swaggerYAMLSpec))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	doc, err := v3.FromJson(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if params := doc.Paths["/pets/{petId}"].Get.Parameters; len(params) != 1 || !params[0].Required || params[0].Schema.Type != v3.Integer {
+		t.Fatalf("expected the required path parameter but got %s", buf)
+	}
+}
+
+const swaggerSpec = `{
+   "swagger":"2.0",
+   "info":{
+      "title":"test",
+      "version":""
+   },
+   "produces":[
+      "application/json"
+   ],
+   "parameters":{
+      "limit":{
+         "name":"limit",
+         "in":"query",
+         "type":"integer"
+      }
+   },
+   "paths":{
+      "/pets":{
+         "get":{
+            "parameters":[
+               {
+                  "$ref":"#/parameters/limit"
+               },
+               {
+                  "name":"tags",
+                  "in":"query",
+                  "type":"array",
+                  "items":{
+                     "type":"string"
+                  }
+               }
+            ],
+            "responses":{
+               "200":{
+                  "description":"",
+                  "schema":{
+                     "type":"array",
+                     "items":{
+                        "$ref":"#/definitions/Pet"
+                     }
+                  }
+               }
+            }
+         },
+         "post":{
+            "parameters":[
+               {
+                  "name":"body",
+                  "in":"body",
+                  "required":true,
+                  "schema":{
+                     "$ref":"#/definitions/Pet"
+                  }
+               }
+            ],
+            "responses":{
+               "204":{
+                  "description":""
+               }
+            }
+         }
+      },
+      "/pets/{petId}/image":{
+         "parameters":[
+            {
+               "name":"petId",
+               "in":"path",
+               "required":true,
+               "type":"integer"
+            }
+         ],
+         "post":{
+            "consumes":[
+               "multipart/form-data"
+            ],
+            "parameters":[
+               {
+                  "name":"file",
+                  "in":"formData",
+                  "type":"file"
+               }
+            ],
+            "responses":{
+               "204":{
+                  "description":""
+               }
+            }
+         }
+      }
+   },
+   "definitions":{
+      "Pet":{
+         "type":"object",
+         "properties":{
+            "name":{
+               "type":"string"
+            },
+            "tags":{
+               "type":"array",
+               "items":{
+                  "$ref":"#/definitions/Tag"
+               }
+            }
+         }
+      },
+      "Tag":{
+         "type":"object",
+         "properties":{
+            "name":{
+               "type":"string"
+            }
+         }
+      }
+   }
+}
+`
+
+const swaggerYAMLSpec = `
+swagger: "2.0"
+info:
+  title: test
+  version: 1.0
+paths:
+  /pets/{petId}:
+    get:
+      parameters:
+        - name: petId
+          in: path
+          required: true
+          type: integer
+      responses:
+        200:
+          description: ""
+`